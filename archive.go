@@ -0,0 +1,307 @@
+package xmind
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"reflect"
+)
+
+type (
+	// ManifestInfo 对应压缩包中的 manifest.json,记录压缩包中的所有文件
+	ManifestInfo struct {
+		FileEntries map[string]*FileEntry `json:"file-entries"`
+	}
+
+	// FileEntry manifest.json 中每个文件的信息
+	FileEntry struct {
+		EncryptionData *EncryptionData `json:"encryption-data,omitempty"` // 加密信息,没有加密时为nil
+	}
+
+	// MetadataInfo 对应压缩包中的 metadata.json,记录创建者等信息
+	MetadataInfo struct {
+		Creator              Creator `json:"creator"`
+		DataStructureVersion string  `json:"dataStructureVersion,omitempty"`
+		LayoutEngineVersion  string  `json:"layoutEngineVersion,omitempty"`
+
+		extra map[string]json.RawMessage // 未定义的字段,保存时原样写回
+	}
+
+	// Creator 创建xmind文件的软件信息
+	Creator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+)
+
+// DefaultCreator WorkBook.Metadata 为空时,保存文件使用的创建者名称
+const DefaultCreator = "github.com/jan-bar/xmind"
+
+var metadataFields = jsonFields(reflect.TypeOf(MetadataInfo{}))
+
+func (mi MetadataInfo) MarshalJSON() ([]byte, error) {
+	type metadata MetadataInfo // 避免递归调用
+	data, err := json.Marshal(metadata(mi))
+	if err != nil {
+		return nil, err
+	}
+	return marshalExtra(data, mi.extra)
+}
+
+func (mi *MetadataInfo) UnmarshalJSON(data []byte) error {
+	type metadata MetadataInfo
+	extra, err := unmarshalExtra(data, (*metadata)(mi), metadataFields)
+	if err != nil {
+		return err
+	}
+	mi.extra = extra
+	return nil
+}
+
+// SetCreator 设置保存文件时写入 metadata.json 的创建者信息
+func (wk *WorkBook) SetCreator(name, version string) {
+	if wk.Metadata == nil {
+		wk.Metadata = newMetadata()
+	}
+	wk.Metadata.Creator = Creator{Name: name, Version: version}
+}
+
+func newMetadata() *MetadataInfo {
+	return &MetadataInfo{
+		Creator:              Creator{Name: DefaultCreator},
+		DataStructureVersion: "2",
+		LayoutEngineVersion:  "3",
+	}
+}
+
+// 压缩包中的文件
+type archiveEntry struct {
+	name string
+	data []byte
+}
+
+// 保存文件时会重新生成的文件,不需要保留
+func regenerated(name string) bool {
+	return name == ContentJson || name == Manifest || name == Metadata
+}
+
+// Entries 返回加载文件时保留的压缩包文件名,以及通过 SetEntry 添加的文件名
+//
+// 不包含保存时会重新生成的文件,例如: content.json,manifest.json,metadata.json
+func (wk *WorkBook) Entries() []string {
+	names := make([]string, len(wk.entries))
+	for i, e := range wk.entries {
+		names[i] = e.name
+	}
+	return names
+}
+
+// Entry 返回压缩包中指定文件的数据
+func (wk *WorkBook) Entry(name string) ([]byte, bool) {
+	for _, e := range wk.entries {
+		if e.name == name {
+			return e.data, true
+		}
+	}
+	return nil, false
+}
+
+// SetEntry 添加或替换压缩包中的文件,保存时原样写入
+//
+// 保存时会重新生成的文件设置无效,主题引用的同名文件以这里设置的数据为准
+func (wk *WorkBook) SetEntry(name string, data []byte) {
+	if regenerated(name) {
+		return
+	}
+	for i, e := range wk.entries {
+		if e.name == name {
+			wk.entries[i].data = data
+			return
+		}
+	}
+	wk.entries = append(wk.entries, archiveEntry{name: name, data: data})
+}
+
+// RemoveEntry 删除压缩包中的文件,保存时不再写入
+func (wk *WorkBook) RemoveEntry(name string) {
+	for i, e := range wk.entries {
+		if e.name == name {
+			wk.entries = append(wk.entries[:i], wk.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// 写入保留的压缩包文件
+func (wk *WorkBook) saveEntries(aw *archiveWriter) error {
+	for _, e := range wk.entries {
+		if regenerated(e.name) || aw.has(e.name) {
+			continue
+		}
+		err := aw.write(e.name, e.data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 写入xmind压缩包,并记录写入的所有文件,最后生成 manifest.json
+type archiveWriter struct {
+	zw       *zip.Writer
+	manifest ManifestInfo
+	password string // 不为空时加密写入的文件,manifest.json 除外
+}
+
+func newArchiveWriter(w io.Writer) *archiveWriter {
+	return &archiveWriter{
+		zw:       zip.NewWriter(w),
+		manifest: ManifestInfo{FileEntries: make(map[string]*FileEntry)},
+	}
+}
+
+// 判断文件已经写入压缩包
+func (aw *archiveWriter) has(name string) bool {
+	_, ok := aw.manifest.FileEntries[name]
+	return ok
+}
+
+func (aw *archiveWriter) write(name string, data []byte) error {
+	fe := &FileEntry{}
+	if aw.password != "" {
+		var err error
+		data, fe.EncryptionData, err = encrypt(data, aw.password)
+		if err != nil {
+			return err
+		}
+	}
+
+	w, err := aw.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	if err != nil {
+		return err
+	}
+	aw.manifest.FileEntries[name] = fe
+	return nil
+}
+
+func (aw *archiveWriter) writeJSON(name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return aw.write(name, data)
+}
+
+// 写入 metadata.json 和 manifest.json,然后关闭压缩包
+func (aw *archiveWriter) close(metadata *MetadataInfo) error {
+	if metadata == nil {
+		metadata = newMetadata()
+	}
+	err := aw.writeJSON(Metadata, metadata)
+	if err != nil {
+		return err
+	}
+
+	// manifest.json 记录的是其他文件,不包含自己
+	w, err := aw.zw.Create(Manifest)
+	if err != nil {
+		return err
+	}
+	err = json.NewEncoder(w).Encode(aw.manifest)
+	if err != nil {
+		return err
+	}
+	return aw.zw.Close()
+}
+
+// 读取压缩包中的 manifest.json,metadata.json 以及主题引用的文件
+// 其他文件全部读取到内存中保留,skip为不需要保留的文件,例如已转换为json的 content.xml
+func (wk *WorkBook) loadArchive(ar *archiveReader, skip ...string) {
+	for _, f := range ar.zr.File {
+		if regenerated(f.Name) || f.FileInfo().IsDir() || contains(skip, f.Name) {
+			continue
+		}
+
+		data, err := ar.read(f.Name)
+		if err == nil { // 无法读取的文件直接忽略
+			wk.entries = append(wk.entries, archiveEntry{name: f.Name, data: data})
+		}
+	}
+
+	wk.Manifest = ar.manifest
+	if data, err := ar.read(Metadata); err == nil {
+		var metadata MetadataInfo
+		if json.Unmarshal(data, &metadata) == nil {
+			wk.Metadata = &metadata
+		}
+	}
+	wk.loadResources(ar)
+}
+
+// 读取xmind压缩包,manifest.json 中有加密信息的文件会使用密码解密
+type archiveReader struct {
+	zr       *zip.Reader
+	manifest *ManifestInfo
+	password string
+}
+
+// 读取压缩包中的 manifest.json,有加密的文件但没有密码时返回 ErrPasswordRequired
+func newArchiveReader(zr *zip.Reader, password string) (*archiveReader, error) {
+	ar := &archiveReader{zr: zr, password: password}
+	if rz, err := zr.Open(Manifest); err == nil {
+		var manifest ManifestInfo
+		if json.NewDecoder(rz).Decode(&manifest) == nil {
+			ar.manifest = &manifest
+		}
+		_ = rz.Close()
+	}
+
+	if password == "" && ar.manifest != nil {
+		for _, fe := range ar.manifest.FileEntries {
+			if fe != nil && fe.EncryptionData != nil {
+				return nil, ErrPasswordRequired
+			}
+		}
+	}
+	return ar, nil
+}
+
+// 读取压缩包中的文件,加密的文件返回解密后的数据
+func (ar *archiveReader) read(name string) ([]byte, error) {
+	rz, err := ar.zr.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(rz)
+	_ = rz.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if ar.encrypted(name) {
+		return ar.manifest.FileEntries[name].EncryptionData.decrypt(name, data, ar.password)
+	}
+	return data, nil
+}
+
+// 判断压缩包中的文件是否加密
+func (ar *archiveReader) encrypted(name string) bool {
+	if ar.manifest == nil {
+		return false
+	}
+	fe := ar.manifest.FileEntries[name]
+	return fe != nil && fe.EncryptionData != nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
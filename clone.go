@@ -0,0 +1,193 @@
+package xmind
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const topicLinkPrefix = "xmind:#" // 链接到其他主题的超链接前缀
+
+// Clone 深拷贝当前主题及所有子孙主题,所有主题,概要,外框以及联系使用 GetId 生成新ID
+//
+//	return
+//		*Topic: 拷贝的主题,拷贝失败时返回nil,需要错误信息时使用 TryClone
+//
+// 子树内部的 xmind:# 主题链接,概要主题ID以及任务依赖会更新为新ID,两端都在子树内的联系
+// 会拷贝到返回主题的 Relationships 中,使用 Paste 粘贴时添加到目标画布
+// 当前主题为画布主题时返回一个新画布,可以直接添加到 WorkBook.Topics 中
+func (st *Topic) Clone() *Topic {
+	cp, err := st.TryClone()
+	if err != nil {
+		return nil
+	}
+	return cp
+}
+
+// TryClone 同 Clone 深拷贝当前主题及所有子孙主题,失败时返回错误
+//
+//	return
+//		*Topic: 拷贝的主题
+//		error: ErrNotFound 当前主题为nil,或者json编解码错误
+func (st *Topic) TryClone() (*Topic, error) {
+	if st == nil {
+		return nil, ErrNotFound
+	}
+
+	// 通过json拷贝可以保留未定义的字段
+	data, err := json.Marshal(st)
+	if err != nil {
+		return nil, err
+	}
+	cp := new(Topic)
+	err = json.Unmarshal(data, cp)
+	if err != nil {
+		return nil, err
+	}
+
+	// json中非标准格式的ID会被替换为随机ID,因此根据源主题建立[旧ID]新ID的对应关系
+	ids := make(map[TopicID]TopicID)
+	cloneWalk(st, cp, func(src, dst *Topic) {
+		id := GetId()
+		ids[src.ID], dst.ID = id, id
+		for _, sm := range dst.Summaries {
+			sm.ID = GetId()
+		}
+		for _, b := range dst.Boundaries {
+			b.ID = GetId()
+		}
+	})
+	cloneWalk(st, cp, func(src, dst *Topic) {
+		dst.attach = src.attach // 拷贝json中不包含的图片和附件数据
+		if src.Image != nil && dst.Image != nil {
+			dst.Image.data = src.Image.data
+		}
+		dst.remapIDs(src, ids)
+	})
+
+	if cp.RootTopic != nil {
+		for i, r := range st.Relationships {
+			rc := cp.Relationships[i]
+			rc.ID, rc.End1ID, rc.End2ID = GetId(), remapID(ids, r.End1ID), remapID(ids, r.End2ID)
+		}
+		cp.initSheet() // 拷贝的画布需要初始化资源信息,同时删除指向不存在主题的联系
+		return cp, nil
+	}
+
+	rels := st.Relationships // 通过 Clone 得到的主题,联系保存在自身
+	if sheet := st.Sheet(); sheet != nil {
+		rels = sheet.Relationships
+	}
+	cp.Relationships = nil
+	for _, r := range rels {
+		end1, ok1 := ids[r.End1ID]
+		end2, ok2 := ids[r.End2ID]
+		if ok1 && ok2 {
+			rc := *r
+			if r.Style != nil {
+				style := *r.Style
+				rc.Style = &style
+			}
+			rc.ID, rc.End1ID, rc.End2ID = GetId(), end1, end2
+			cp.Relationships = append(cp.Relationships, &rc)
+		}
+	}
+	return cp, nil
+}
+
+// 按照相同顺序遍历源主题和拷贝的主题,src和dst结构相同
+func cloneWalk(src, dst *Topic, f func(src, dst *Topic)) {
+	f(src, dst)
+	if src.RootTopic != nil && dst.RootTopic != nil {
+		cloneWalk(src.RootTopic, dst.RootTopic, f)
+	}
+
+	sc, dc := src.Children.topics(), dst.Children.topics()
+	for i := 0; i < len(sc) && i < len(dc); i++ {
+		cloneWalk(sc[i], dc[i], f)
+	}
+}
+
+// 返回旧ID对应的新ID,不在ids中的ID保持不变
+func remapID(ids map[TopicID]TopicID, id TopicID) TopicID {
+	if newID, ok := ids[id]; ok {
+		return newID
+	}
+	return id
+}
+
+// 根据源主题src引用的ID,将当前主题引用的ID更新为新ID,不在ids中的ID保持不变
+func (st *Topic) remapIDs(src *Topic, ids map[TopicID]TopicID) {
+	for i, sm := range src.Summaries {
+		if i < len(st.Summaries) {
+			st.Summaries[i].TopicID = remapID(ids, sm.TopicID)
+		}
+	}
+	if strings.HasPrefix(src.Href, topicLinkPrefix) {
+		st.Href = topicLinkPrefix + string(remapID(ids, TopicID(src.Href[len(topicLinkPrefix):])))
+	}
+
+	if task := st.Task(); task != nil && len(task.Dependencies) > 0 {
+		changed := false
+		for i, dep := range task.Dependencies {
+			if id, ok := ids[dep]; ok {
+				task.Dependencies[i], changed = id, true
+			}
+		}
+		if changed {
+			st.SetTask(task)
+		}
+	}
+}
+
+// Paste 将主题拷贝后粘贴到当前节点对应位置,可以在不同画布或 WorkBook 之间拷贝
+//
+//	param
+//		subtree: 要粘贴的主题,可以是任意画布中的主题或 Clone 的返回值,为画布主题时粘贴中心主题
+//		modes: 粘贴的添加方式,不支持 ParentMode,不传则默认粘贴为最后一个子主题
+//	return
+//		*Topic: 当前主题地址
+//
+// 每次粘贴都会重新拷贝,subtree 不会被修改,可以多次粘贴
+func (st *Topic) Paste(subtree *Topic, modes ...AddMode) *Topic {
+	tp, _ := st.TryPaste(subtree, modes...)
+	return tp
+}
+
+// TryPaste 同 Paste 将主题拷贝后粘贴到当前节点对应位置,失败时返回错误
+//
+//	return
+//		*Topic: 当前主题地址
+//		error: ErrRootNotEditable 当前为根节点,ErrNotFound 当前主题已被删除或 subtree 为nil
+func (st *Topic) TryPaste(subtree *Topic, modes ...AddMode) (*Topic, error) {
+	if err := st.editable(); err != nil {
+		return st, err // 同 Add 根节点不支持操作
+	}
+	if subtree != nil && subtree.RootTopic != nil {
+		subtree = subtree.RootTopic // 粘贴画布时粘贴中心主题
+	}
+
+	mode := SubMode
+	if len(modes) > 0 {
+		mode = modes[0].In()
+		if mode == ParentMode {
+			mode = SubMode // 粘贴方式不支持将节点粘贴为当前节点父节点
+		}
+	}
+
+	cp, err := subtree.TryClone()
+	if err != nil {
+		return st, err
+	}
+	rels := cp.Relationships
+	cp.Relationships, cp.Position = nil, nil // 粘贴后作为普通子主题,不需要保留自由主题的坐标
+
+	st.insert(cp, mode)
+	cp.resources = st.resources
+	st.resources[cp.ID] = cp
+	cp.upChildren(make(map[TopicID]TopicID))
+
+	if sheet := st.Sheet(); sheet != nil {
+		sheet.Relationships = append(sheet.Relationships, rels...)
+	}
+	return st, nil
+}
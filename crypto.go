@@ -0,0 +1,199 @@
+package xmind
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// 加密参数和xmind保持一致
+//
+//goland:noinspection SpellCheckingInspection
+const (
+	AlgorithmAESCBC     = "AES/CBC/PKCS5Padding" // 加密算法
+	KeyDerivationPBKDF2 = "PBKDF2WithHmacSHA512" // 密钥派生算法
+	ChecksumMD5         = "MD5"                  // 校验和算法,校验解密后的数据
+
+	encryptIterations = 1024 // 密钥派生迭代次数
+	encryptKeySize    = 128  // 密钥长度,单位bit
+	encryptSaltSize   = 16
+)
+
+type (
+	// EncryptionData manifest.json 中加密文件的加密信息,二进制数据使用base64编码
+	EncryptionData struct {
+		AlgorithmName     string `json:"algorithm-name"`
+		KeyDerivationName string `json:"key-derivation-name"`
+		IterationCount    int    `json:"iteration-count"`
+		Size              int    `json:"size"` // 密钥长度,单位bit
+		Salt              string `json:"salt"`
+		IV                string `json:"iv"`
+		ChecksumType      string `json:"checksum-type"`
+		Checksum          string `json:"checksum"`
+	}
+
+	// PasswordError 密码错误,解密 Entry 文件失败
+	PasswordError struct {
+		Entry string
+	}
+)
+
+// ErrPasswordRequired 加载加密的xmind文件时没有传密码
+var ErrPasswordRequired = errors.New("xmind is encrypted, password required")
+
+func (e *PasswordError) Error() string {
+	return fmt.Sprintf("wrong password, can not decrypt %s", e.Entry)
+}
+
+// WithPassword 保存时使用密码加密,除 manifest.json 外的文件都会加密
+//
+// 加密时不会生成缩略图,避免泄露内容,加载文件时保留的缩略图也会被加密
+func WithPassword(password string) SaveOption {
+	return func(o *saveOption) { o.password = password }
+}
+
+// SaveEncrypted 使用密码加密保存为 *.xmind 文件,使用 LoadFromWithPassword 加载
+func (wk *WorkBook) SaveEncrypted(path, password string) error {
+	if password == "" {
+		return ErrPasswordRequired
+	}
+	return wk.Save(path, WithPassword(password))
+}
+
+// 使用PBKDF2算法派生密钥,参考 RFC 8018
+func pbkdf2(password, salt []byte, iter, keyLen int, h func() hash.Hash) []byte {
+	prf := hmac.New(h, password)
+	hashLen := prf.Size()
+	blocks := (keyLen + hashLen - 1) / hashLen
+
+	var (
+		key = make([]byte, 0, blocks*hashLen)
+		buf [4]byte
+		u   = make([]byte, hashLen)
+	)
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf[:], uint32(block))
+		prf.Write(buf[:])
+		u = prf.Sum(u[:0])
+
+		t := make([]byte, hashLen)
+		copy(t, u)
+		for n := 2; n <= iter; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for i := range t {
+				t[i] ^= u[i]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
+
+// 根据加密信息生成密钥,不支持的算法返回错误
+func (ed *EncryptionData) key(password string) ([]byte, error) {
+	if ed.AlgorithmName != AlgorithmAESCBC || ed.KeyDerivationName != KeyDerivationPBKDF2 {
+		return nil, fmt.Errorf("unsupported encryption: %s, %s", ed.AlgorithmName, ed.KeyDerivationName)
+	}
+
+	size := ed.Size / 8
+	if size != 16 && size != 24 && size != 32 {
+		return nil, fmt.Errorf("unsupported key size: %d", ed.Size)
+	}
+	salt, err := base64.StdEncoding.DecodeString(ed.Salt)
+	if err != nil {
+		return nil, err
+	}
+	return pbkdf2([]byte(password), salt, ed.IterationCount, size, sha512.New), nil
+}
+
+// 使用密码加密数据,返回加密后的数据和加密信息
+func encrypt(data []byte, password string) ([]byte, *EncryptionData, error) {
+	salt := make([]byte, encryptSaltSize)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, nil, err
+	}
+
+	sum := md5.Sum(data)
+	ed := &EncryptionData{
+		AlgorithmName:     AlgorithmAESCBC,
+		KeyDerivationName: KeyDerivationPBKDF2,
+		IterationCount:    encryptIterations,
+		Size:              encryptKeySize,
+		Salt:              base64.StdEncoding.EncodeToString(salt),
+		IV:                base64.StdEncoding.EncodeToString(iv),
+		ChecksumType:      ChecksumMD5,
+		Checksum:          base64.StdEncoding.EncodeToString(sum[:]),
+	}
+	key, err := ed.key(password)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// PKCS5填充,数据长度为块大小整数倍时填充一整块
+	pad := aes.BlockSize - len(data)%aes.BlockSize
+	out := append(append(make([]byte, 0, len(data)+pad), data...), bytes.Repeat([]byte{byte(pad)}, pad)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, out)
+	return out, ed, nil
+}
+
+// 使用密码解密name文件的数据,密码错误时返回 *PasswordError
+func (ed *EncryptionData) decrypt(name string, data []byte, password string) ([]byte, error) {
+	if password == "" {
+		return nil, ErrPasswordRequired
+	}
+	key, err := ed.key(password)
+	if err != nil {
+		return nil, err
+	}
+	iv, err := base64.StdEncoding.DecodeString(ed.IV)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize || len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("%s: invalid encrypted data", name)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+
+	// 密码错误时填充和校验和大概率不正确
+	pad := int(out[len(out)-1])
+	if pad == 0 || pad > aes.BlockSize || !bytes.Equal(out[len(out)-pad:],
+		bytes.Repeat([]byte{byte(pad)}, pad)) {
+		return nil, &PasswordError{Entry: name}
+	}
+	out = out[:len(out)-pad]
+
+	if ed.ChecksumType == ChecksumMD5 && ed.Checksum != "" {
+		sum := md5.Sum(out)
+		if base64.StdEncoding.EncodeToString(sum[:]) != ed.Checksum {
+			return nil, &PasswordError{Entry: name}
+		}
+	}
+	return out, nil
+}
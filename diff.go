@@ -0,0 +1,331 @@
+package xmind
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ChangeKind 主题变化类型
+type ChangeKind string
+
+const (
+	ChangeAdded     ChangeKind = "added"     // 新增主题
+	ChangeRemoved   ChangeKind = "removed"   // 删除主题
+	ChangeMoved     ChangeKind = "moved"     // 父主题发生变化
+	ChangeReordered ChangeKind = "reordered" // 父主题不变,在兄弟主题中的顺序发生变化
+	ChangeRenamed   ChangeKind = "renamed"   // 主题内容发生变化
+	ChangeAttr      ChangeKind = "changed"   // 主题属性发生变化,例如: 超链接,标签,备注
+)
+
+type (
+	// Change 两个 WorkBook 之间一个主题的变化
+	Change struct {
+		Kind     ChangeKind
+		Sheet    string   // 主题所在画布名称,删除的主题为旧画布名称
+		Path     string   // 主题路径,参考 Topic.Path,删除的主题为旧路径
+		OldSheet string   // 移动,重命名,属性变化时主题的旧画布名称
+		OldPath  string   // 移动,重命名,属性变化时主题的旧路径
+		Attrs    []string // 发生变化的属性名称,只有 ChangeAttr 有值
+		OldIndex int      // 重新排序时在旧父主题普通子主题中的下标,只有 ChangeReordered 有值
+		Index    int      // 重新排序时在新父主题普通子主题中的下标,只有 ChangeReordered 有值
+		Old      *Topic   // 旧主题,新增主题时为nil
+		New      *Topic   // 新主题,删除主题时为nil
+	}
+
+	// Changes Diff 的结果,使用 String 生成可读的文本
+	Changes []Change
+)
+
+// 参与比较的主题属性,主题内容和位置单独比较
+var diffAttrs = []struct {
+	name  string
+	value func(*Topic) any
+}{
+	{"type", func(tp *Topic) any { return tp.Type() }},
+	{"href", func(tp *Topic) any { return tp.Href }},
+	{"branch", func(tp *Topic) any { return tp.Branch }},
+	{"structureClass", func(tp *Topic) any { return tp.StructureClass }},
+	{"labels", func(tp *Topic) any { return nilIfEmpty(tp.Labels) }},
+	{"markers", func(tp *Topic) any { return nilIfEmpty(tp.markerIds()) }},
+	{"notes", func(tp *Topic) any { return tp.Notes }},
+	{"image", func(tp *Topic) any { return tp.Image }},
+	{"style", func(tp *Topic) any {
+		// 样式ID只用于xmind8格式,不参与比较
+		return []any{tp.Style.Properties, tp.Style.Type}
+	}},
+	{"position", func(tp *Topic) any { return tp.Position }},
+	{"numbering", func(tp *Topic) any { return tp.Numbering }},
+	{"extensions", func(tp *Topic) any {
+		if len(tp.Extensions) == 0 {
+			return nil
+		}
+		return tp.Extensions
+	}},
+	{"summaries", func(tp *Topic) any {
+		var res []string
+		for _, sm := range tp.Summaries {
+			res = append(res, sm.Range)
+		}
+		return res
+	}},
+	{"boundaries", func(tp *Topic) any {
+		var res []string
+		for _, b := range tp.Boundaries {
+			res = append(res, b.Range+"|"+b.Title)
+		}
+		return res
+	}},
+}
+
+func nilIfEmpty(s []string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
+
+// Diff 比较两个 WorkBook 中所有主题的变化
+//
+//	param
+//		a: 旧版本
+//		b: 新版本
+//	return
+//		Changes: 所有变化,先按照b中主题顺序列出新增,移动,重新排序,重命名和属性变化,再按照a中主题顺序列出删除
+//
+// 主题先按照ID匹配,剩余的主题在已匹配的父主题下按照主题内容匹配,即按照路径匹配
+// 加载文件时非标准格式的ID会重新生成,这类主题只能通过路径匹配
+// 兄弟主题保持相对顺序的最长序列视为未移动,其余普通子主题视为重新排序,
+// 因此新增或删除兄弟主题导致的下标变化不会被当作重新排序
+func Diff(a, b *WorkBook) Changes {
+	var (
+		sheetsA, topicsA = diffTopics(a)
+		sheetsB, topicsB = diffTopics(b)
+
+		pairs = make(map[*Topic]*Topic) // a中主题 => b中主题,包括画布
+		rev   = make(map[*Topic]*Topic) // b中主题 => a中主题
+	)
+	pair := func(ta, tb *Topic) {
+		pairs[ta], rev[tb] = tb, ta
+	}
+
+	// 画布先按照ID匹配,再按照画布名称匹配
+	for _, match := range []func(sa, sb *Topic) bool{
+		func(sa, sb *Topic) bool { return sa.ID == sb.ID },
+		func(sa, sb *Topic) bool { return sa.Title == sb.Title },
+	} {
+		for _, sb := range sheetsB {
+			for _, sa := range sheetsA {
+				if rev[sb] == nil && pairs[sa] == nil && match(sa, sb) {
+					pair(sa, sb)
+				}
+			}
+		}
+	}
+
+	// 主题按照ID匹配
+	ids := make(map[TopicID]*Topic, len(topicsA))
+	for _, ta := range topicsA {
+		ids[ta.ID] = ta
+	}
+	for _, tb := range topicsB {
+		if ta, ok := ids[tb.ID]; ok && pairs[ta] == nil {
+			pair(ta, tb)
+		}
+	}
+
+	// 已匹配画布的中心主题直接匹配
+	for _, sa := range sheetsA {
+		if sb := pairs[sa]; sb != nil && sa.RootTopic != nil && sb.RootTopic != nil &&
+			pairs[sa.RootTopic] == nil && rev[sb.RootTopic] == nil {
+			pair(sa.RootTopic, sb.RootTopic)
+		}
+	}
+
+	// 按照b中主题顺序,父主题总是先于子主题匹配,因此可以逐级按照路径匹配
+	for _, tb := range topicsB {
+		ta := rev[tb]
+		if ta == nil {
+			continue
+		}
+		children := ta.Children.topics()
+		for _, cb := range tb.Children.topics() {
+			if rev[cb] != nil {
+				continue
+			}
+			for _, ca := range children {
+				if pairs[ca] == nil && ca.Title == cb.Title {
+					pair(ca, cb)
+					break
+				}
+			}
+		}
+	}
+
+	reordered := diffReordered(topicsB, rev)
+
+	var res Changes
+	for _, tb := range topicsB {
+		ta := rev[tb]
+		if ta == nil {
+			res = append(res, Change{Kind: ChangeAdded, Sheet: topicSheet(tb).Title, Path: tb.Path(), New: tb})
+			continue
+		}
+
+		c := Change{
+			Sheet:    topicSheet(tb).Title,
+			Path:     tb.Path(),
+			OldSheet: topicSheet(ta).Title,
+			OldPath:  ta.Path(),
+			Old:      ta,
+			New:      tb,
+		}
+		if pairs[ta.parent] != tb.parent {
+			c.Kind = ChangeMoved
+			res = append(res, c)
+		} else if idx, ok := reordered[tb]; ok {
+			c.Kind, c.OldIndex, c.Index = ChangeReordered, idx[0], idx[1]
+			res = append(res, c)
+		}
+		if ta.Title != tb.Title {
+			c.Kind = ChangeRenamed
+			res = append(res, c)
+		}
+		for _, attr := range diffAttrs {
+			if !sameJSON(attr.value(ta), attr.value(tb)) {
+				c.Attrs = append(c.Attrs, attr.name)
+			}
+		}
+		if len(c.Attrs) > 0 {
+			c.Kind = ChangeAttr
+			res = append(res, c)
+		}
+	}
+	for _, ta := range topicsA {
+		if pairs[ta] == nil {
+			res = append(res, Change{Kind: ChangeRemoved, Sheet: topicSheet(ta).Title, Path: ta.Path(), Old: ta})
+		}
+	}
+	return res
+}
+
+// 返回父主题不变但顺序发生变化的普通子主题,值为[旧下标,新下标]
+func diffReordered(topicsB []*Topic, rev map[*Topic]*Topic) map[*Topic][2]int {
+	res := make(map[*Topic][2]int)
+	for _, tb := range topicsB {
+		ta := rev[tb]
+		if ta == nil || ta.Children == nil || tb.Children == nil {
+			continue
+		}
+
+		index := make(map[*Topic]int, len(ta.Children.Attached))
+		for i, ca := range ta.Children.Attached {
+			index[ca] = i
+		}
+		var (
+			siblings []*Topic // b中父主题不变的普通子主题
+			old, cur []int    // 对应的旧下标和新下标
+		)
+		for i, cb := range tb.Children.Attached {
+			if j, ok := index[rev[cb]]; ok {
+				siblings, old, cur = append(siblings, cb), append(old, j), append(cur, i)
+			}
+		}
+
+		keep := longestIncreasing(old)
+		for i, cb := range siblings {
+			if !keep[i] {
+				res[cb] = [2]int{old[i], cur[i]}
+			}
+		}
+	}
+	return res
+}
+
+// 返回最长递增子序列,keep[i]为true表示nums[i]在该序列中
+func longestIncreasing(nums []int) []bool {
+	var (
+		length = make([]int, len(nums)) // 以nums[i]结尾的最长递增子序列长度
+		prev   = make([]int, len(nums)) // 序列中nums[i]的前一个元素下标
+		last   = -1
+	)
+	for i := range nums {
+		length[i], prev[i] = 1, -1
+		for j := 0; j < i; j++ {
+			if nums[j] < nums[i] && length[j]+1 > length[i] {
+				length[i], prev[i] = length[j]+1, j
+			}
+		}
+		if last < 0 || length[i] > length[last] {
+			last = i
+		}
+	}
+
+	keep := make([]bool, len(nums))
+	for i := last; i >= 0; i = prev[i] {
+		keep[i] = true
+	}
+	return keep
+}
+
+// 返回所有画布,以及所有画布中的主题,主题按照在画布中的顺序排列,为nil的画布会被忽略
+func diffTopics(wk *WorkBook) (sheets, topics []*Topic) {
+	if wk == nil {
+		return
+	}
+	for _, topic := range wk.Topics {
+		if topic == nil {
+			continue
+		}
+		sheet := topicSheet(topic)
+		sheets = append(sheets, sheet)
+		_ = sheet.Range(func(_ int, tp *Topic) error {
+			topics = append(topics, tp)
+			return nil
+		})
+	}
+	return
+}
+
+// 返回主题所在画布,通过父节点查找,支持没有资源信息的主题
+func topicSheet(tp *Topic) *Topic {
+	for tp != nil && tp.parent != nil {
+		tp = tp.parent
+	}
+	return tp
+}
+
+func sameJSON(x, y any) bool {
+	dx, errX := json.Marshal(x)
+	dy, errY := json.Marshal(y)
+	return errX == nil && errY == nil && bytes.Equal(dx, dy)
+}
+
+// String 生成可读的文本,每个变化一行,行首字符表示变化类型,
+// 依次为: + 新增,- 删除,> 移动,^ 重新排序,~ 重命名,* 属性变化,例如:
+//
+//	> [sheet1] /中心主题/A/B -> [sheet1] /中心主题/B
+//	^ [sheet1] /中心主题/C: 2 -> 0
+//	~ [sheet1] /中心主题/B: "旧内容" -> "新内容"
+//	* [sheet1] /中心主题/B: labels,notes
+func (cs Changes) String() string {
+	var sb strings.Builder
+	for _, c := range cs {
+		switch c.Kind {
+		case ChangeAdded:
+			_, _ = fmt.Fprintf(&sb, "+ [%s] %s\n", c.Sheet, c.Path)
+		case ChangeRemoved:
+			_, _ = fmt.Fprintf(&sb, "- [%s] %s\n", c.Sheet, c.Path)
+		case ChangeMoved:
+			_, _ = fmt.Fprintf(&sb, "> [%s] %s -> [%s] %s\n", c.OldSheet, c.OldPath, c.Sheet, c.Path)
+		case ChangeReordered:
+			_, _ = fmt.Fprintf(&sb, "^ [%s] %s: %d -> %d\n", c.Sheet, c.Path, c.OldIndex, c.Index)
+		case ChangeRenamed:
+			_, _ = fmt.Fprintf(&sb, "~ [%s] %s: %q -> %q\n", c.Sheet, c.Path, c.Old.Title, c.New.Title)
+		case ChangeAttr:
+			_, _ = fmt.Fprintf(&sb, "* [%s] %s: %s\n", c.Sheet, c.Path, strings.Join(c.Attrs, ","))
+		}
+	}
+	return sb.String()
+}
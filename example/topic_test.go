@@ -0,0 +1,830 @@
+package example
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jan-bar/xmind"
+)
+
+// go test -v -run TestDetached
+func TestDetached(t *testing.T) {
+	st := xmind.NewSheet("sheet1", "main topic")
+	st.Add("123").AddDetached("float1", 100, -50).AddDetached("float2", 200, 50)
+	if len(st.Detached()) != 2 {
+		t.Fatal("detached != 2")
+	}
+
+	var types []xmind.TopicType
+	_ = st.Range(func(_ int, tp *xmind.Topic) error {
+		types = append(types, tp.Type())
+		return nil
+	})
+	if len(types) != 4 || types[0] != xmind.TopicRoot || types[1] != xmind.TopicAttached ||
+		types[2] != xmind.TopicDetached || types[3] != xmind.TopicDetached {
+		t.Fatalf("range types: %v", types)
+	}
+
+	var buf bytes.Buffer
+	err := (&xmind.WorkBook{Topics: []*xmind.Topic{st}}).SaveTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wb, err := xmind.LoadFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cent := wb.Topics[0].On()
+	if ds := cent.Detached(); len(ds) != 2 || ds[1].Title != "float2" ||
+		ds[1].Position == nil || ds[1].Position.X != 200 {
+		t.Fatalf("detached not loaded: %v", ds)
+	}
+
+	// 自由主题可以被查找和删除
+	cent.RemoveByID(cent.CId("float1"))
+	if len(cent.Detached()) != 1 {
+		t.Fatal("remove detached failed")
+	}
+	cent.OnTitle("123").Move(cent.CId("float2"))
+	if len(cent.Detached()) != 0 || cent.OnTitle("float2").Type() != xmind.TopicAttached {
+		t.Fatal("move detached failed")
+	}
+}
+
+// go test -v -run TestSummary
+func TestSummary(t *testing.T) {
+	st := xmind.NewSheet("sheet1", "main topic")
+	st.Add("a").Add("b").Add("c").Add("d").AddSummary(1, 2, "b-c")
+
+	cent := st.On()
+	if len(cent.Summaries) != 1 || cent.Summaries[0].Range != "(1,2)" ||
+		cent.SummaryTopic(cent.Summaries[0]).Title != "b-c" {
+		t.Fatal("add summary failed")
+	}
+
+	cent.OnTitle("a").Add("x", xmind.AfterMode) // a,x,b,c,d
+	if cent.Summaries[0].Range != "(2,3)" {
+		t.Fatalf("after add: %s", cent.Summaries[0].Range)
+	}
+
+	cent.OnTitle("b").Move(cent.CId("d"), xmind.AfterMode) // a,x,b,d,c
+	if cent.Summaries[0].Range != "(2,4)" {
+		t.Fatalf("after move: %s", cent.Summaries[0].Range)
+	}
+
+	cent.RemoveByID(cent.CId("b")) // a,x,d,c
+	if cent.Summaries[0].Range != "(2,3)" {
+		t.Fatalf("after remove: %s", cent.Summaries[0].Range)
+	}
+
+	// 移出概要范围的子主题不再包含在范围内
+	cent.OnTitle("a").Move(cent.CId("d"), xmind.BeforeMode) // d,a,x,c
+	if cent.Summaries[0].Range != "(3,3)" {
+		t.Fatalf("after move out: %s", cent.Summaries[0].Range)
+	}
+	cent.OnTitle("a").Move(cent.CId("d"), xmind.AfterMode) // a,d,x,c
+
+	// 概要主题不是普通子主题,在其前后添加时作为它的子主题
+	cent.OnTitle("b-c").Add("y", xmind.BeforeMode)
+	if tp := cent.OnTitle("y"); tp.Parent().Title != "b-c" || cent.Children.Attached[0].Title != "a" {
+		t.Fatal("add before summary topic failed")
+	}
+	cent.OnTitle("b-c").RemoveByID(cent.CId("y"))
+
+	var buf bytes.Buffer
+	err := (&xmind.WorkBook{Topics: []*xmind.Topic{st}}).SaveTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wb, err := xmind.LoadFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cent = wb.Topics[0].On()
+	if len(cent.Summaries) != 1 || cent.OnTitle("b-c").Type() != xmind.TopicSummary {
+		t.Fatal("load summary failed")
+	}
+
+	// 概要包含的子主题全部删除时,概要也一起删除
+	cent.RemoveByID(cent.CId("c")).RemoveByID(cent.CId("d"))
+	if len(cent.Summaries) != 0 || cent.CId("b-c") != cent.CId("not found") {
+		t.Fatal("summary should be removed")
+	}
+
+	// 删除所有子主题时,概要也一起删除
+	cent.Add("e").Add("f").AddSummary(0, 1, "e-f").RemoveChildren()
+	if len(cent.Summaries) != 0 || cent.Children != nil {
+		t.Fatalf("remove children: %+v", cent.Summaries)
+	}
+}
+
+// go test -v -run TestBoundary
+func TestBoundary(t *testing.T) {
+	st := xmind.NewSheet("sheet1", "main topic")
+	st.Add("a").Add("b").Add("c").AddBoundary(0, 1, "a-b").
+		OnTitle("c").AddBoundary(-1, 0, "all").AddLabel("label")
+
+	var buf bytes.Buffer
+	err := (&xmind.WorkBook{Topics: []*xmind.Topic{st}}).SaveTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wb, err := xmind.LoadFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cent := wb.Topics[0].On()
+	if len(cent.Boundaries) != 1 || cent.Boundaries[0].Title != "a-b" ||
+		cent.Boundaries[0].Range != "(0,1)" {
+		t.Fatal("load boundary failed")
+	}
+	if bs := cent.OnTitle("c").Boundaries; len(bs) != 1 || bs[0].Range != xmind.BoundaryMaster {
+		t.Fatal("load master boundary failed")
+	}
+
+	wb, err = xmind.LoadFrom(bytes.NewBufferString(`<xmap-content><sheet id="s"><title>s</title>
+<topic id="c"><title>cent</title><children><topics type="attached">
+<topic id="t1"><title>t1</title></topic><topic id="t2"><title>t2</title></topic>
+</topics></children><boundaries><boundary id="b1" range="(0,1)"><title>xml</title></boundary>
+</boundaries></topic></sheet></xmap-content>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bs := wb.Topics[0].On().Boundaries; len(bs) != 1 || bs[0].Title != "xml" {
+		t.Fatal("load xml boundary failed")
+	}
+
+	// 删除所有子主题时,只保留包含整个主题的外框
+	a := st.OnTitle("a")
+	a.Add("a1").Add("a2").AddBoundary(0, 1, "a1-a2").AddBoundary(-1, 0, "all")
+	a.RemoveChildren()
+	if len(a.Boundaries) != 1 || a.Boundaries[0].Range != xmind.BoundaryMaster {
+		t.Fatalf("remove children: %+v", a.Boundaries)
+	}
+}
+
+// go test -v -run TestRelationship
+func TestRelationship(t *testing.T) {
+	st := xmind.NewSheet("sheet1", "main topic")
+	st.Add("a").Add("b").OnTitle("a").Add("a1").OnTitle("b").Add("b1")
+	st.Relate(st.CId("a1"), st.CId("b1"), "depends").
+		Relate(xmind.CentKey, st.CId("b"), "").
+		Relate(st.CId("a1"), "not found", "")
+	if rs := st.Sheet().Relationships; len(rs) != 2 || rs[0].Title != "depends" ||
+		rs[1].End1ID != st.On().ID {
+		t.Fatalf("relate failed: %v", rs)
+	}
+
+	var buf bytes.Buffer
+	err := (&xmind.WorkBook{Topics: []*xmind.Topic{st}}).SaveTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wb, err := xmind.LoadFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cent := wb.Topics[0].On()
+	if rs := cent.Sheet().Relationships; len(rs) != 2 || rs[0].End2ID != cent.CId("b1") {
+		t.Fatal("load relationship failed")
+	}
+
+	cent.RemoveByID(cent.CId("a"))
+	if rs := cent.Sheet().Relationships; len(rs) != 1 || rs[0].End1ID != cent.ID {
+		t.Fatal("relationship should be removed")
+	}
+
+	// xml中的非普通ID会重新生成,联系需要同步更新
+	wb, err = xmind.LoadFrom(bytes.NewBufferString(`<xmap-content><sheet id="s"><title>s</title>
+<topic id="c"><title>cent</title><children><topics type="attached">
+<topic id="t1"><title>t1</title></topic><topic id="t2"><title>t2</title></topic>
+</topics></children></topic><relationships><relationship id="r1" end1="t1" end2="c">
+<title>xml</title></relationship></relationships></sheet></xmap-content>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cent = wb.Topics[0].On()
+	if rs := cent.Sheet().Relationships; len(rs) != 1 || rs[0].End1ID != cent.CId("t1") ||
+		rs[0].End2ID != cent.ID || !cent.ID.IsOrdinary() {
+		t.Fatal("load xml relationship failed")
+	}
+}
+
+// go test -v -run TestMarker
+func TestMarker(t *testing.T) {
+	st := xmind.NewSheet("sheet1", "main topic")
+	st.Add("a").OnTitle("a").AddMarker(xmind.MarkerPriority1, xmind.MarkerFlagRed).
+		AddMarker(xmind.MarkerPriority3) // 同一组图标会被替换
+	a := st.OnTitle("a")
+	if len(a.Markers) != 2 || !a.HasMarker(xmind.MarkerPriority3) || a.HasMarker(xmind.MarkerPriority1) {
+		t.Fatalf("add marker failed: %v", a.Markers)
+	}
+	if a.RemoveMarker(xmind.MarkerFlagRed); len(a.Markers) != 1 {
+		t.Fatal("remove marker failed")
+	}
+
+	var data string
+	err := xmind.SaveCustom(st, map[string]string{xmind.CustomKeyMarkers: "m"}, &data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc, err := xmind.LoadCustom(data, map[string]string{xmind.CustomKeyMarkers: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sc.OnTitle("a").HasMarker(xmind.MarkerPriority3) || len(sc.On().Markers) != 0 {
+		t.Fatalf("custom markers failed: %s", data)
+	}
+	if strings.Count(data, `"m":`) != 1 {
+		t.Fatalf("empty markers should be omitted: %s", data) // 中心主题没有图标
+	}
+
+	var md bytes.Buffer
+	err = (&xmind.WorkBook{Topics: []*xmind.Topic{st}}).SaveToMarkdown(&md, map[string]string{
+		xmind.DefaultMarkdownName: "{{.Title}}{{range .Markers}} [{{.}}]{{end}}\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.String() != "main topic\na [priority-3]\n" {
+		t.Fatalf("markdown markers failed: %q", md.String())
+	}
+}
+
+// go test -v -run TestRichNotes
+func TestRichNotes(t *testing.T) {
+	st := xmind.NewSheet("sheet1", "main topic")
+	st.Add("a").OnTitle("a").AddRichNotes("intro **bold** and [link](https://xmind.net)\n\n- one\n- *two*")
+
+	notes := st.OnTitle("a").Notes
+	if notes.Plain.Content != "intro bold and link\n- one\n- two" {
+		t.Fatalf("plain: %q", notes.Plain.Content)
+	}
+
+	var md bytes.Buffer
+	err := (&xmind.WorkBook{Topics: []*xmind.Topic{st}}).SaveToMarkdown(&md, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# main topic\n\n## a\n\nintro **bold** and [link](https://xmind.net)\n\n- one\n- *two*\n\n"
+	if md.String() != want {
+		t.Fatalf("markdown: %q", md.String())
+	}
+}
+
+// go test -v -run TestStyle
+func TestStyle(t *testing.T) {
+	st := xmind.NewSheet("sheet1", "main topic")
+	st.Add("a").OnTitle("a").SetFill("#ff0000").SetFont("Arial", 14, "#ffffff").
+		SetBold(true).SetBorder("#000000", 2).SetLine(xmind.LineElbow, "#00ff00").
+		SetShape(xmind.ShapeEllipse).Style.Properties.Set("fo:text-decoration", "underline")
+
+	var buf bytes.Buffer
+	err := (&xmind.WorkBook{Topics: []*xmind.Topic{st}}).SaveTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wb, err := xmind.LoadFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	style := wb.Topics[0].OnTitle("a").Style
+	if !style.Id.IsOrdinary() || style.Type != "topic" {
+		t.Fatalf("style id: %q, type: %q", style.Id, style.Type)
+	}
+	sp := style.Properties
+	if sp.Fill != "#ff0000" || sp.FontSize != "14pt" || sp.FontWeight != "bold" ||
+		sp.BorderWidth != "2pt" || sp.LineClass != xmind.LineElbow || sp.ShapeClass != xmind.ShapeEllipse {
+		t.Fatalf("style properties: %+v", sp)
+	}
+	if sp.Get("fo:text-decoration") != "underline" {
+		t.Fatal("unknown style property lost")
+	}
+}
+
+// go test -v -run TestTask
+func TestTask(t *testing.T) {
+	due := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	st := xmind.NewSheet("sheet1", "main topic")
+	st.Add("a").OnTitle("a").SetTask(&xmind.TaskInfo{
+		Progress:     0.5,
+		Priority:     2,
+		Start:        due.Add(-48 * time.Hour),
+		Due:          due,
+		Duration:     48 * time.Hour,
+		Assignee:     "jan",
+		Dependencies: []xmind.TopicID{st.CId("main topic")},
+	})
+
+	var buf bytes.Buffer
+	err := (&xmind.WorkBook{Topics: []*xmind.Topic{st}}).SaveTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wb, err := xmind.LoadFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := wb.Topics[0].OnTitle("a")
+	task := a.Task()
+	if task == nil || task.Progress != 0.5 || task.Priority != 2 || !task.Due.Equal(due) ||
+		task.Due.Location() != time.UTC ||
+		!task.Start.Equal(due.Add(-48*time.Hour)) || task.Duration != 48*time.Hour ||
+		task.Assignee != "jan" || len(task.Dependencies) != 1 {
+		t.Fatalf("task: %+v", task)
+	}
+	if a.Extensions[0].Provider != xmind.ExtensionTask {
+		t.Fatalf("extensions: %+v", a.Extensions)
+	}
+
+	// 自定义数据中的任务进度和截止时间
+	var data string
+	err = xmind.SaveCustom(wb.Topics[0], nil, &data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(data, `"progress":0.5,"due":"2024-01-02T15:04:05Z"`) {
+		t.Fatalf("save custom: %s", data)
+	}
+	sheet, err := xmind.LoadCustom(data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task = sheet.OnTitle("a").Task(); task == nil || task.Progress != 0.5 || !task.Due.Equal(due) {
+		t.Fatalf("load custom: %+v", task)
+	}
+	if sheet.On().Task() != nil {
+		t.Fatal("central topic has no task")
+	}
+
+	a.SetTask(nil)
+	if a.Task() != nil || len(a.Extensions) != 0 {
+		t.Fatal("remove task")
+	}
+
+	// 加载时重新生成的非普通ID,任务依赖需要指向新ID
+	st = xmind.NewSheet("sheet1", "main topic")
+	st.Add("a").Add("b")
+	bid := st.CId("b")
+	st.OnTitle("a").SetTask(&xmind.TaskInfo{Dependencies: []xmind.TopicID{bid}})
+	_, zr := saveZip(t, &xmind.WorkBook{Topics: []*xmind.Topic{st}})
+	wb, err = xmind.LoadFrom(bytes.NewReader(replaceEntry(t, zr, xmind.ContentJson, string(bid), "t1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bid = wb.Topics[0].CId("b")
+	if task = wb.Topics[0].OnTitle("a").Task(); !bid.IsOrdinary() ||
+		task == nil || len(task.Dependencies) != 1 || task.Dependencies[0] != bid {
+		t.Fatalf("load dependencies: %+v", task)
+	}
+}
+
+// go test -v -run TestNumbering
+func TestNumbering(t *testing.T) {
+	st := xmind.NewSheet("sheet1", "main topic")
+	st.Add("a").Add("b").OnTitle("b").Add("b1").Add("b2").OnTitle("b2").Add("b21")
+	st.On().SetNumbering(&xmind.Numbering{
+		NumberFormat:      xmind.NumberArabic,
+		PrependingNumbers: true,
+	}, true)
+	st.On().SetNumbering(&xmind.Numbering{
+		NumberFormat: xmind.NumberRoman,
+		Prefix:       "Part ",
+		Suffix:       ":",
+	})
+
+	for title, want := range map[string]string{
+		"a": "Part I:", "b": "Part II:", "b1": "II.1", "b2": "II.2", "b21": "II.2.1",
+	} {
+		if num := st.OnTitle(title).Number(); num != want {
+			t.Fatalf("%s: %q != %q", title, num, want)
+		}
+	}
+	if st.On().Number() != "" {
+		t.Fatal("central topic has no number")
+	}
+	if xmind.NumberLowerLetter.Format(28) != "ab" || xmind.NumberRoman.Format(1994) != "MCMXCIV" {
+		t.Fatal("number format")
+	}
+
+	// 插入父主题时,子主题编号跟随原主题的子主题移动
+	x := st.Add("x").OnTitle("x")
+	x.SetNumbering(&xmind.Numbering{NumberFormat: xmind.NumberArabic}).Add("c1").Add("c2")
+	x.Add("p", xmind.ParentMode)
+	if c1, c2 := st.OnTitle("c1").Number(), st.OnTitle("c2").Number(); c1 != "1" || c2 != "2" {
+		t.Fatalf("parent mode: %q %q", c1, c2)
+	}
+	st.On().Remove("p")
+
+	var buf bytes.Buffer
+	err := (&xmind.WorkBook{Topics: []*xmind.Topic{st}}).SaveTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wb, err := xmind.LoadFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if num := wb.Topics[0].OnTitle("b21").Number(); num != "II.2.1" {
+		t.Fatalf("load: %q", num)
+	}
+
+	var md bytes.Buffer
+	err = wb.SaveToMarkdown(&md, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(md.String(), "## Part II: b\n") || !strings.Contains(md.String(), "#### II.2.1 b21\n") {
+		t.Fatalf("markdown: %s", md.String())
+	}
+
+	var data string
+	err = xmind.SaveCustom(wb.Topics[0], nil, &data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(data, `"number":"II.2.1"`) {
+		t.Fatalf("save custom: %s", data)
+	}
+}
+
+// go test -v -run TestCallout
+func TestCallout(t *testing.T) {
+	st := xmind.NewSheet("sheet1", "main topic")
+	st.Add("a").OnTitle("a").AddCallout("remember").AddCallout("check")
+	if len(st.OnTitle("a").Callouts()) != 2 {
+		t.Fatal("callouts != 2")
+	}
+
+	var buf bytes.Buffer
+	err := (&xmind.WorkBook{Topics: []*xmind.Topic{st}}).SaveTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wb, err := xmind.LoadFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var callouts []string
+	_ = wb.Topics[0].Range(func(_ int, tp *xmind.Topic) error {
+		if tp.Type() == xmind.TopicCallout {
+			callouts = append(callouts, tp.Title)
+		}
+		return nil
+	})
+	if strings.Join(callouts, ",") != "remember,check" {
+		t.Fatalf("callouts: %v", callouts)
+	}
+
+	var md bytes.Buffer
+	err = wb.SaveToMarkdown(&md, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# main topic\n\n## a\n\n> [!NOTE]\n> remember\n\n> [!NOTE]\n> check\n\n"
+	if md.String() != want {
+		t.Fatalf("markdown: %q", md.String())
+	}
+
+	a := wb.Topics[0].OnTitle("a")
+	a.RemoveByID(a.Callouts()[0].ID)
+	if len(a.Callouts()) != 1 || a.Children != nil && len(a.Children.Attached) != 0 {
+		t.Fatalf("remove callout: %+v", a.Children)
+	}
+
+	// 自定义结构只保存普通子主题,自由主题,概要主题和标注不会变成普通子主题
+	cent := wb.Topics[0].On()
+	cent.Add("b").AddSummary(0, 1, "a-b").AddDetached("floating", 100, 100).
+		OnTitle("a-b").Add("sum child")
+	var data string
+	err = xmind.SaveCustom(wb.Topics[0], nil, &data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc, err := xmind.LoadCustom(data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	_ = sc.Range(func(_ int, tp *xmind.Topic) error {
+		titles = append(titles, tp.Title)
+		return nil
+	})
+	if strings.Join(titles, ",") != "main topic,a,b" {
+		t.Fatalf("custom round trip: %v, %s", titles, data)
+	}
+}
+
+// go test -v -run TestEquation
+func TestEquation(t *testing.T) {
+	st := xmind.NewSheet("sheet1", "main topic")
+	st.Add("energy").OnTitle("energy").SetEquation(`E=mc^2`).
+		SetTask(&xmind.TaskInfo{Progress: 1})
+
+	var buf bytes.Buffer
+	err := (&xmind.WorkBook{Topics: []*xmind.Topic{st}}).SaveTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wb, err := xmind.LoadFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tp := wb.Topics[0].OnTitle("energy")
+	if tp.Equation() != `E=mc^2` || tp.Task() == nil {
+		t.Fatalf("equation: %q", tp.Equation())
+	}
+
+	var md bytes.Buffer
+	err = wb.SaveToMarkdown(&md, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# main topic\n\n## energy\n\n$$\nE=mc^2\n$$\n\n"
+	if md.String() != want {
+		t.Fatalf("markdown: %q", md.String())
+	}
+
+	tp.SetEquation(`\frac{a}{b}`)
+	if tp.Equation() != `\frac{a}{b}` || len(tp.Extensions) != 2 {
+		t.Fatalf("update equation: %q", tp.Equation())
+	}
+	tp.SetEquation("")
+	if tp.Equation() != "" || len(tp.Extensions) != 1 {
+		t.Fatal("remove equation")
+	}
+}
+
+// go test -v -run TestTry
+func TestTry(t *testing.T) {
+	st := xmind.NewSheet("sheet1", "main topic")
+	st.Add("a").Add("b")
+	a, b := st.CId("a"), st.CId("b")
+	st.On(a).Add("a1")
+	a1 := st.CId("a1")
+
+	if _, err := st.Lookup("not exist"); !errors.Is(err, xmind.ErrNotFound) {
+		t.Fatalf("lookup: %v", err)
+	}
+	if _, err := st.Lookup("last"); !errors.Is(err, xmind.ErrSpecialID) {
+		t.Fatalf("lookup special: %v", err)
+	}
+	tp, err := st.Lookup(a1)
+	if err != nil || tp.Title != "a1" {
+		t.Fatalf("lookup a1: %v", err)
+	}
+	if cent, err := st.Lookup(xmind.CentKey); err != nil || !cent.IsCent() {
+		t.Fatalf("lookup cent: %v", err)
+	}
+
+	if _, err = st.On(a1).TryMove(a); !errors.Is(err, xmind.ErrCyclicMove) {
+		t.Fatalf("move into descendant: %v", err)
+	}
+	if _, err = st.On(a).TryMove(a); !errors.Is(err, xmind.ErrCyclicMove) {
+		t.Fatalf("move into itself: %v", err)
+	}
+	if _, err = st.On(b).TryMove(st.On().ID); !errors.Is(err, xmind.ErrRootNotEditable) {
+		t.Fatalf("move central topic: %v", err)
+	}
+	if _, err = st.On(b).TryMove("last"); !errors.Is(err, xmind.ErrSpecialID) {
+		t.Fatalf("move special: %v", err)
+	}
+	if _, err = st.On(b).TryMove(a1); err != nil || st.Parent(a1).ID != b {
+		t.Fatalf("move a1 to b: %v", err)
+	}
+
+	if _, err = st.Parent().TryAdd("x"); !errors.Is(err, xmind.ErrRootNotEditable) {
+		t.Fatalf("add to root: %v", err)
+	}
+	removed := st.On(a)
+	if _, err = st.TryRemove(a); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = removed.TryAdd("x"); !errors.Is(err, xmind.ErrNotFound) {
+		t.Fatalf("add to removed topic: %v", err)
+	}
+	// 链式方法保持原有行为,已删除的主题上仍然可以添加
+	if removed.Add("y"); removed.Children == nil || len(removed.Children.Attached) != 1 ||
+		removed.Children.Attached[0].Title != "y" {
+		t.Fatalf("chained add to removed topic: %+v", removed.Children)
+	}
+	if _, err = st.TryRemove(a); !errors.Is(err, xmind.ErrNotFound) {
+		t.Fatalf("remove again: %v", err)
+	}
+	if _, err = st.TryRemove("incr"); !errors.Is(err, xmind.ErrSpecialID) {
+		t.Fatalf("remove special: %v", err)
+	}
+	if _, err = st.TryRemove(st.On().ID); !errors.Is(err, xmind.ErrRootNotEditable) {
+		t.Fatalf("remove central topic: %v", err)
+	}
+}
+
+// go test -v -run TestSelect
+func TestSelect(t *testing.T) {
+	st := xmind.NewSheet("sheet1", "main topic")
+	st.Add("Backend").Add("Frontend").Add("a/b")
+	st.OnTitle("Backend").Add("Overview").Add("API")
+	st.OnTitle("Frontend").Add("Overview")
+	st.OnTitle("API").Add("Auth").Add("Users")
+	st.OnTitle("Auth").AddLabel("v1")
+	st.OnTitle("Users").AddLabel("v2")
+
+	auth := st.On().OnPath("Backend/API/Auth")
+	if auth.Title != "Auth" || auth.Path() != "/main topic/Backend/API/Auth" {
+		t.Fatalf("path: %s", auth.Path())
+	}
+	if tp, err := st.LookupPath(auth.Path()); err != nil || tp != auth {
+		t.Fatalf("absolute path: %v", err)
+	}
+	if tp := st.On().OnPath(`a\/b`); tp.Title != "a/b" || tp.Path() != `/main topic/a\/b` {
+		t.Fatalf("escape: %s", tp.Path())
+	}
+	if _, err := st.LookupPath("Backend/Auth"); !errors.Is(err, xmind.ErrNotFound) {
+		t.Fatalf("not found: %v", err)
+	}
+	if tp := st.OnPath("Backend").OnPath("Overview"); tp.Path() != "/main topic/Backend/Overview" {
+		t.Fatalf("relative path: %s", tp.Path())
+	}
+
+	titles := func(tps []*xmind.Topic) string {
+		res := make([]string, 0, len(tps))
+		for _, tp := range tps {
+			res = append(res, tp.Title)
+		}
+		return strings.Join(res, ",")
+	}
+	for selector, want := range map[string]string{
+		"/**/Overview":           "Overview,Overview",
+		"/main topic/*":          "Backend,Frontend,a/b",
+		"/**[label]":             "Auth,Users",
+		"/**/*[label=v1]":        "Auth",
+		"/**[depth<=2]":          "main topic,Backend,Frontend,a/b",
+		"/main topic/Backend/**": "Overview,API,Auth,Users",
+		"/**[depth>3][label=v2]": "Users",
+		"/**/Missing":            "",
+	} {
+		got, err := st.TrySelect(selector)
+		if err != nil || titles(got) != want {
+			t.Fatalf("%s: %s != %s, %v", selector, titles(got), want, err)
+		}
+	}
+	if got := titles(st.OnPath("Backend").Select("**/Auth")); got != "Auth" {
+		t.Fatalf("relative select: %s", got)
+	}
+	for _, selector := range []string{"/**[unknown]", "/*[depth<x]", "/*[label"} {
+		if _, err := st.TrySelect(selector); err == nil {
+			t.Fatalf("%s: invalid selector should return error", selector)
+		}
+		if st.Select(selector) != nil {
+			t.Fatalf("%s: invalid selector should select nothing", selector)
+		}
+	}
+
+	// 内容为空的主题对应一级空路径,Path 的返回值可以重新定位到该主题
+	empty := st.OnTitle("Frontend")
+	empty.Title = ""
+	overview := empty.OnPath("Overview")
+	if empty.Path() != "/main topic/" || overview.Path() != "/main topic//Overview" {
+		t.Fatalf("empty title path: %q, %q", empty.Path(), overview.Path())
+	}
+	if st.OnPath(empty.Path()) != empty || st.OnPath(overview.Path()) != overview ||
+		titles(st.Select("/main topic//*")) != "Overview" {
+		t.Fatal("empty title path can not be resolved")
+	}
+}
+
+// go test -v -run TestFind
+func TestFind(t *testing.T) {
+	st1 := xmind.NewSheet("sheet1", "main topic")
+	st1.Add("Overview").Add("API v1").Add("API v2")
+	st1.OnTitle("Overview").AddNotes("read the docs first").AddHref("https://github.com")
+	st1.OnTitle("API v1").AddLabel("deprecated").Add("Auth")
+	st2 := xmind.NewSheet("sheet2", "second")
+	st2.Add("API v3").OnTitle("API v3").AddHref("xmind:#" + string(st1.CId("Auth")))
+
+	if res := st1.Find(xmind.MatchTitle(regexp.MustCompile(`^API v\d$`))); len(res) != 2 ||
+		res[0].Title != "API v1" || res[1].Title != "API v2" {
+		t.Fatalf("title: %v", res)
+	}
+	if tp := st1.FindFirst(xmind.MatchNotes("docs")); tp == nil || tp.Title != "Overview" {
+		t.Fatalf("notes: %v", tp)
+	}
+	if tp := st1.FindFirst(xmind.MatchLabel("")); tp == nil || tp.Title != "API v1" {
+		t.Fatalf("label: %v", tp)
+	}
+	if tp := st1.FindFirst(xmind.MatchHrefScheme("HTTPS")); tp == nil || tp.Title != "Overview" {
+		t.Fatalf("href: %v", tp)
+	}
+	if res := st1.Find(xmind.MatchDepth(3, 0)); len(res) != 1 || res[0].Title != "Auth" {
+		t.Fatalf("depth: %v", res)
+	}
+	if tp := st1.FindFirst(xmind.MatchLabel("not exist")); tp != nil {
+		t.Fatalf("not found: %v", tp)
+	}
+
+	st1.On(st1.CId("Auth"))   // 切换最后编辑的主题,查找后保持不变
+	st2.On(st2.CId("API v3")) // 找不到主题时 On 会返回最后编辑的主题
+	wb := &xmind.WorkBook{Topics: []*xmind.Topic{st1, st2}}
+	res := wb.Find(xmind.MatchAll(
+		xmind.MatchTitle(regexp.MustCompile(`^API`)),
+		xmind.MatchAny(xmind.MatchLabel("deprecated"), xmind.MatchHrefScheme("xmind")),
+	))
+	if len(res) != 2 || res[0].Index != 0 || res[0].Sheet.Title != "sheet1" || res[0].Topic.Title != "API v1" ||
+		res[1].Index != 1 || res[1].Sheet.Title != "sheet2" || res[1].Topic.Title != "API v3" {
+		t.Fatalf("workbook: %+v", res)
+	}
+	if first := wb.FindFirst(xmind.MatchTitle(regexp.MustCompile("second"))); first == nil ||
+		first.Index != 1 || !first.Topic.IsCent() {
+		t.Fatalf("workbook first: %+v", first)
+	}
+	if st1.On("not exist").Title != "Auth" || st2.On("not exist").Title != "API v3" {
+		t.Fatal("find should not change the last edited topic")
+	}
+}
+
+// go test -v -run TestClone
+func TestClone(t *testing.T) {
+	st1 := xmind.NewSheet("sheet1", "main topic")
+	st1.Add("Backend").OnTitle("Backend").Add("API").Add("DB").AddSummary(0, 1, "layers")
+	api, db := st1.CId("API"), st1.CId("DB")
+	st1.On(api).AddHref("xmind:#" + string(db)).AddLabel("v1").SetTask(&xmind.TaskInfo{Dependencies: []xmind.TopicID{db}})
+	if err := st1.On(db).AddAttachment("schema.sql", strings.NewReader("create table")); err != nil {
+		t.Fatal(err)
+	}
+	st1.Relate(api, db, "uses")
+
+	backend := st1.OnTitle("Backend")
+	cp := backend.Clone()
+	if cp == nil || cp.ID == backend.ID || cp.Title != "Backend" || len(cp.Children.Attached) != 2 ||
+		len(cp.Relationships) != 1 || len(cp.Summaries) != 1 {
+		t.Fatalf("clone: %+v", cp)
+	}
+
+	st2 := xmind.NewSheet("sheet2", "second")
+	st2.Add("first").OnTitle("first").Paste(cp, xmind.AfterMode)
+	st2.On().Paste(backend)
+	pasted := st2.Select("/second/Backend")
+	if len(pasted) != 2 || st2.On().Children.Attached[0].Title != "first" {
+		t.Fatalf("paste: %v", pasted)
+	}
+
+	papi, pdb := pasted[0].Children.Attached[0], pasted[0].Children.Attached[1]
+	if papi.ID == api || pdb.ID == db || papi.Href != "xmind:#"+string(pdb.ID) ||
+		papi.Task().Dependencies[0] != pdb.ID || papi.Labels[0] != "v1" ||
+		string(pdb.AttachmentData()) != "create table" {
+		t.Fatalf("pasted topics: %+v, %+v", papi, pdb)
+	}
+	if sm := pasted[0].Summaries[0]; sm.TopicID != pasted[0].Children.Summary[0].ID {
+		t.Fatalf("summary: %+v", sm)
+	}
+	if tp, err := st2.Lookup(pdb.ID); err != nil || tp.Parent() != pasted[0] {
+		t.Fatalf("resources: %v", err)
+	}
+	if rels := st2.Sheet().Relationships; len(rels) != 2 ||
+		rels[0].End1ID != papi.ID || rels[0].End2ID != pdb.ID {
+		t.Fatalf("relationships: %+v", rels)
+	}
+
+	// 源主题不会被修改
+	if st1.On(api).Href != "xmind:#"+string(db) || len(st1.Sheet().Relationships) != 1 {
+		t.Fatal("source topic changed")
+	}
+
+	// 拷贝整个画布
+	sheet := st1.Sheet().Clone()
+	wb := &xmind.WorkBook{Topics: []*xmind.Topic{st1, sheet}}
+	buf, _ := saveZip(t, wb)
+	wb, err := xmind.LoadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := wb.Topics[1].On(); c.Title != "main topic" || c.ID == st1.ID ||
+		len(wb.Topics[1].Relationships) != 1 || len(c.Select("Backend/*")) != 3 {
+		t.Fatalf("clone sheet: %+v", c)
+	}
+
+	// 非标准格式的ID在json中会被替换,引用该ID的概要和链接仍然需要更新为新ID
+	layers := backend.SummaryTopic(backend.Summaries[0])
+	layers.ID, backend.Summaries[0].TopicID = "layers", "layers"
+	layers.AddHref("xmind:#layers")
+	cp, err = backend.TryClone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sm := cp.SummaryTopic(cp.Summaries[0]); sm == nil || sm.Title != "layers" ||
+		sm.Href != "xmind:#"+string(sm.ID) || !sm.ID.IsOrdinary() {
+		t.Fatalf("clone non-ordinary id: %+v, %+v", cp.Summaries[0], cp.Children.Summary[0])
+	}
+	if _, err = (*xmind.Topic)(nil).TryClone(); !errors.Is(err, xmind.ErrNotFound) {
+		t.Fatalf("clone nil: %v", err)
+	}
+}
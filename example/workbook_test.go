@@ -0,0 +1,592 @@
+package example
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jan-bar/xmind"
+)
+
+// 将workbook保存到内存中,并打开对应的压缩包
+func saveZip(t *testing.T, wb *xmind.WorkBook, opts ...xmind.SaveOption) (*bytes.Buffer, *zip.Reader) {
+	var buf bytes.Buffer
+	if err := wb.SaveTo(&buf, opts...); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &buf, zr
+}
+
+// 替换压缩包中name文件的内容,将old全部替换为new,其他文件保持不变
+func replaceEntry(t *testing.T, zr *zip.Reader, name, old, new string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range zr.File {
+		rz, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rz)
+		_ = rz.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Name == name {
+			data = []byte(strings.ReplaceAll(string(data), old, new))
+		}
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// go test -v -run TestImage
+func TestImage(t *testing.T) {
+	var img bytes.Buffer
+	err := png.Encode(&img, image.NewGray(image.Rect(0, 0, 4, 4)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st := xmind.NewSheet("sheet1", "main topic")
+	st.Add("chart")
+	err = st.OnTitle("chart").AddImage("chart.PNG", bytes.NewReader(img.Bytes()), 40, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf, zr := saveZip(t, &xmind.WorkBook{Topics: []*xmind.Topic{st}})
+	name := st.OnTitle("chart").Image.Path()
+	if !strings.HasPrefix(name, xmind.Resources+"/") || !strings.HasSuffix(name, ".png") {
+		t.Fatalf("image path: %s", name)
+	}
+	if _, err = zr.Open(name); err != nil {
+		t.Fatal(err)
+	}
+
+	wb, err := xmind.LoadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := wb.Topics[0].OnTitle("chart").Image
+	if loaded.Width != 40 || !bytes.Equal(loaded.Data(), img.Bytes()) {
+		t.Fatal("load image failed")
+	}
+}
+
+// go test -v -run TestAttachment
+func TestAttachment(t *testing.T) {
+	st := xmind.NewSheet("sheet1", "main topic")
+	st.Add("payload.json").Add("other")
+	err := st.OnTitle("payload.json").AddAttachment("payload.json", strings.NewReader(`{"a":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = st.OnTitle("other").AddAttachment("data.bin", strings.NewReader("binary"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf, _ := saveZip(t, &xmind.WorkBook{Topics: []*xmind.Topic{st}})
+	wb, err := xmind.LoadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ats := wb.Attachments()
+	if len(ats) != 2 || ats[0].Name != "payload.json" || string(ats[0].Data) != `{"a":1}` ||
+		!strings.HasSuffix(ats[1].Name, ".bin") || string(ats[1].Topic.AttachmentData()) != "binary" {
+		t.Fatalf("load attachments failed: %v", ats)
+	}
+
+	dir := t.TempDir()
+	if err = wb.ExtractAttachments(dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, at := range ats {
+		data, err := os.ReadFile(filepath.Join(dir, at.Name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, at.Data) {
+			t.Fatalf("extract %s failed: %q", at.Name, data)
+		}
+	}
+}
+
+// go test -v -run TestRoundTrip
+func TestRoundTrip(t *testing.T) {
+	src, err := os.ReadFile("rich.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wb, err := xmind.LoadFrom(bytes.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(wb.Topics[0].Extra("topicPositioning")) != `"fixed"` {
+		t.Fatal("sheet extra field lost")
+	}
+
+	_, zr := saveZip(t, wb)
+	rz, err := zr.Open(xmind.ContentJson)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst, err := io.ReadAll(rz)
+	_ = rz.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 加载再保存后,json语义必须和原始文件完全一致
+	var want, got any
+	if err = json.Unmarshal(src, &want); err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(dst, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("round trip changed content:\n%s", dst)
+	}
+}
+
+// go test -v -run TestManifest
+func TestManifest(t *testing.T) {
+	st := xmind.NewSheet("sheet1", "main topic")
+	err := st.AddAttachment("a.txt", strings.NewReader("attachment"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wb := &xmind.WorkBook{Topics: []*xmind.Topic{st}}
+	wb.SetCreator("report-generator", "1.2.0")
+	buf, _ := saveZip(t, wb)
+
+	wb, err = xmind.LoadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wb.Metadata == nil || wb.Metadata.Creator.Name != "report-generator" ||
+		wb.Metadata.Creator.Version != "1.2.0" {
+		t.Fatalf("metadata: %+v", wb.Metadata)
+	}
+	if wb.Manifest == nil || len(wb.Manifest.FileEntries) != 3 ||
+		wb.Manifest.FileEntries[xmind.ContentJson] == nil ||
+		wb.Manifest.FileEntries[xmind.Metadata] == nil ||
+		wb.Manifest.FileEntries[st.Href[len("xap:"):]] == nil {
+		t.Fatalf("manifest: %+v", wb.Manifest)
+	}
+}
+
+// go test -v -run TestEncrypt
+func TestEncrypt(t *testing.T) {
+	st := xmind.NewSheet("sheet1", "secret topic")
+	err := st.AddAttachment("a.txt", strings.NewReader("attachment"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wb := &xmind.WorkBook{Topics: []*xmind.Topic{st}}
+	buf, zr := saveZip(t, wb, xmind.WithPassword("123456"))
+	if _, ok := zr.Open(xmind.ThumbnailPath); ok == nil {
+		t.Fatal("encrypted xmind should not contain thumbnail")
+	}
+	if bytes.Contains(buf.Bytes(), []byte("secret topic")) {
+		t.Fatal("content.json is not encrypted")
+	}
+
+	_, err = xmind.LoadFrom(bytes.NewReader(buf.Bytes()))
+	if !errors.Is(err, xmind.ErrPasswordRequired) {
+		t.Fatalf("load without password: %v", err)
+	}
+	_, err = xmind.LoadFromWithPassword(bytes.NewReader(buf.Bytes()), "654321")
+	var pe *xmind.PasswordError
+	if !errors.As(err, &pe) || pe.Entry != xmind.ContentJson {
+		t.Fatalf("load with wrong password: %v", err)
+	}
+
+	// 不支持的加密参数直接返回对应错误
+	for _, tc := range []struct{ old, new, want string }{
+		{xmind.AlgorithmAESCBC, "AES/GCM/NoPadding", "unsupported encryption"},
+		{`"size":128`, `"size":100`, "unsupported key size"},
+	} {
+		data := replaceEntry(t, zr, xmind.Manifest, tc.old, tc.new)
+		_, err = xmind.LoadFromWithPassword(bytes.NewReader(data), "123456")
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("load %s: %v", tc.new, err)
+		}
+	}
+
+	wb, err = xmind.LoadFromWithPassword(bytes.NewReader(buf.Bytes()), "123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cent := wb.Topics[0].On()
+	if cent.Title != "secret topic" || string(cent.AttachmentData()) != "attachment" {
+		t.Fatalf("topic: %+v", cent)
+	}
+	if fe := wb.Manifest.FileEntries[xmind.ContentJson]; fe == nil || fe.EncryptionData == nil ||
+		fe.EncryptionData.AlgorithmName != xmind.AlgorithmAESCBC {
+		t.Fatalf("manifest: %+v", wb.Manifest)
+	}
+}
+
+// go test -v -run TestEntries
+func TestEntries(t *testing.T) {
+	var src bytes.Buffer
+	zw := zip.NewWriter(&src)
+	for name, data := range map[string]string{
+		xmind.ContentJson:            `[{"id":"1","title":"s","rootTopic":{"id":"2","title":"c"}}]`,
+		"Thumbnails/thumbnail.png":   "png",
+		"styles/custom.json":         "{}",
+		xmind.Resources + "/old.txt": "old",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	wb, err := xmind.LoadFrom(&src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wb.Entries()) != 3 {
+		t.Fatalf("entries: %v", wb.Entries())
+	}
+	wb.RemoveEntry(xmind.Resources + "/old.txt")
+	wb.SetEntry("styles/custom.json", []byte(`{"a":1}`))
+	wb.Topics[0].On().Add("new")
+
+	_, zr := saveZip(t, wb)
+	names := make(map[string]bool)
+	for _, f := range zr.File {
+		names[f.Name] = true
+	}
+	if !names["Thumbnails/thumbnail.png"] || !names["styles/custom.json"] ||
+		names[xmind.Resources+"/old.txt"] || !names[xmind.Manifest] {
+		t.Fatalf("saved entries: %v", names)
+	}
+
+	rz, err := zr.Open("styles/custom.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rz)
+	_ = rz.Close()
+	if string(data) != `{"a":1}` {
+		t.Fatalf("replaced entry: %s", data)
+	}
+}
+
+// go test -v -run TestThumbnail
+func TestThumbnail(t *testing.T) {
+	for _, sc := range []xmind.StructureClass{xmind.StructMapUnbalanced,
+		xmind.StructLogicLeft, xmind.StructOrgChartDown, xmind.StructOrgChartUp} {
+		st := xmind.NewSheet("sheet", "中心主题", sc)
+		st.Add("a").Add("b").Add("c").On(st.CId("a")).Add("a1").Add("a2")
+		wb := &xmind.WorkBook{Topics: []*xmind.Topic{st}}
+
+		_, zr := saveZip(t, wb, xmind.WithThumbnail())
+		rz, err := zr.Open(xmind.ThumbnailPath)
+		if err != nil {
+			t.Fatal(sc, err)
+		}
+		img, err := png.Decode(rz)
+		_ = rz.Close()
+		if err != nil {
+			t.Fatal(sc, err)
+		}
+		b := img.Bounds()
+		if b.Dx() <= 0 || b.Dy() <= 0 {
+			t.Fatalf("%s: bounds %v", sc, b)
+		}
+		drawn := 0 // 除白色背景外绘制的像素
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				if r, g, bb, _ := img.At(x, y).RGBA(); r&g&bb != 0xffff {
+					drawn++
+				}
+			}
+		}
+		if drawn == 0 {
+			t.Fatalf("%s: nothing drawn", sc)
+		}
+
+		// 默认不生成缩略图,保留加载文件时的缩略图
+		wb.SetEntry(xmind.ThumbnailPath, []byte("png"))
+		_, zr = saveZip(t, wb)
+		rz, err = zr.Open(xmind.ThumbnailPath)
+		if err != nil {
+			t.Fatal(sc, err)
+		}
+		data, _ := io.ReadAll(rz)
+		_ = rz.Close()
+		if string(data) != "png" {
+			t.Fatalf("%s: keep thumbnail: %q", sc, data)
+		}
+	}
+
+	// 为nil的画布不影响保存,缩略图使用第一个不为nil的画布生成
+	wb := &xmind.WorkBook{Topics: []*xmind.Topic{nil, xmind.NewSheet("sheet", "main topic")}}
+	_, zr := saveZip(t, wb, xmind.WithThumbnail())
+	if _, err := zr.Open(xmind.ThumbnailPath); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := wb.SaveToXML(&buf, xmind.WithThumbnail()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// go test -v -run TestSaveLegacy
+func TestSaveLegacy(t *testing.T) {
+	st := xmind.NewSheet("sheet1", "main topic", xmind.StructMapClockwise)
+	st.Add("a").Add("b").Add("c").AddSummary(0, 1, "sum").AddBoundary(1, 2, "box")
+	st.On(st.CId("a")).AddLabel("l1").AddHref("https://github.com").
+		AddMarker(xmind.MarkerPriority1).AddRichNotes("**bold** text").
+		SetFill("#ff0000").Add("a1").Folded()
+	err := st.On(st.CId("b")).AddImage("chart.png", strings.NewReader("img"), 10, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.Relate(st.CId("a"), st.CId("c"), "depends")
+
+	var buf bytes.Buffer
+	wb := &xmind.WorkBook{Topics: []*xmind.Topic{st}}
+	if err = wb.SaveToXML(&buf); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rz, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rz)
+		_ = rz.Close()
+		files[f.Name] = string(data)
+	}
+	if _, ok := files[xmind.ContentJson]; ok {
+		t.Fatal("legacy archive contains content.json")
+	}
+	for name, want := range map[string][]string{
+		xmind.ContentXml: {`xmlns="urn:xmind:xmap:xmlns:content:2.0"`,
+			`structure-class="org.xmind.ui.map"`, `branch="folded"`,
+			`xlink:href="https://github.com"`, `<marker-ref marker-id="priority-1">`,
+			`<labels><label>l1</label></labels>`, `<plain>bold text</plain>`,
+			`<xhtml:span style="font-weight:bold;">bold</xhtml:span>`,
+			`<topics type="summary">`, `<boundary id=`, `<relationship id=`,
+			`xhtml:src="xap:attachments/`},
+		xmind.StylesXml:   {`<topic-properties svg:fill="#ff0000">`},
+		xmind.MetaXml:     {xmind.DefaultCreator},
+		xmind.ManifestXml: {`full-path="content.xml" media-type="text/xml"`},
+	} {
+		for _, w := range want {
+			if !strings.Contains(files[name], w) {
+				t.Fatalf("%s: missing %s\n%s", name, w, files[name])
+			}
+		}
+	}
+
+	wb, err = xmind.LoadFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cent := wb.Topics[0].On()
+	if cent.Title != "main topic" || len(cent.Children.Attached) != 3 ||
+		len(cent.Children.Summary) != 1 || len(wb.Topics[0].Relationships) != 1 {
+		t.Fatalf("load legacy: %+v", cent)
+	}
+	b := cent.On(cent.CId("b"))
+	if string(b.Image.Data()) != "img" {
+		t.Fatalf("image: %+v", b.Image)
+	}
+}
+
+// go test -v -run TestLoadLegacy
+func TestLoadLegacy(t *testing.T) {
+	var src bytes.Buffer
+	zw := zip.NewWriter(&src)
+	for name, data := range map[string]string{
+		xmind.ContentXml: `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<xmap-content xmlns="urn:xmind:xmap:xmlns:content:2.0" xmlns:fo="http://www.w3.org/1999/XSL/Format" xmlns:svg="http://www.w3.org/2000/svg" xmlns:xhtml="http://www.w3.org/1999/xhtml" xmlns:xlink="http://www.w3.org/1999/xlink" version="2.0">
+<sheet id="s1"><topic id="c1" structure-class="org.xmind.ui.logic.right" style-id="st1"><title>center</title>
+<children><topics type="attached">
+<topic id="t1" xlink:href="https://github.com" branch="folded"><title>a</title>
+<xhtml:img svg:width="10" svg:height="20" xhtml:src="xap:attachments/chart.png"/>
+<marker-refs><marker-ref marker-id="priority-1"/><marker-ref marker-id="task-done"/></marker-refs>
+<notes><plain>bold text</plain><html><xhtml:p><xhtml:span style="font-weight:bold;">bold</xhtml:span> text</xhtml:p></html></notes>
+</topic>
+<topic id="t2"><title>b</title></topic>
+</topics><topics type="summary"><topic id="t3"><title>sum</title></topic></topics></children>
+<boundaries><boundary id="b1" range="(0,1)" style-id="st2"><title>box</title></boundary></boundaries>
+<summaries><summary id="m1" range="(0,1)" topic-id="t3"/></summaries>
+</topic><title>sheet</title>
+<relationships><relationship id="r1" end1="t1" end2="t2" style-id="st3"><title>rel</title></relationship></relationships>
+</sheet></xmap-content>`,
+		xmind.StylesXml: `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<xmap-styles xmlns="urn:xmind:xmap:xmlns:style:2.0" xmlns:fo="http://www.w3.org/1999/XSL/Format" xmlns:svg="http://www.w3.org/2000/svg" version="2.0">
+<automatic-styles><style id="st1" type="topic"><topic-properties svg:fill="#ff0000" fo:font-weight="bold" line-class="org.xmind.branchConnection.curve" fo:text-align="left"/></style>
+<style id="st2" type="boundary"><boundary-properties svg:fill="#00ff00"/></style>
+<style id="st3" type="relationship"><relationship-properties line-color="#0000ff"/></style></automatic-styles>
+</xmap-styles>`,
+		"attachments/chart.png": "img",
+		xmind.ManifestXml:       "<manifest/>",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	wb, err := xmind.LoadFrom(&src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet := wb.Topics[0]
+	cent := sheet.On()
+	if p := cent.Style.Properties; p.Fill != "#ff0000" || p.FontWeight != "bold" ||
+		p.LineClass != xmind.LineCurve || p.Get("fo:text-align") != "left" || cent.Style.Type != "topic" {
+		t.Fatalf("style: %+v", cent.Style)
+	}
+	if len(cent.Boundaries) != 1 || cent.Boundaries[0].Style.Properties.Fill != "#00ff00" ||
+		len(cent.Summaries) != 1 || len(cent.Children.Summary) != 1 {
+		t.Fatalf("boundaries: %+v, summaries: %+v", cent.Boundaries, cent.Summaries)
+	}
+	if len(sheet.Relationships) != 1 || sheet.Relationships[0].Title != "rel" ||
+		sheet.Relationships[0].Style.Properties.LineColor != "#0000ff" {
+		t.Fatalf("relationships: %+v", sheet.Relationships)
+	}
+
+	a := cent.Children.Attached[0]
+	if a.Href != "https://github.com" || a.Branch != "folded" || a.Style.Id != "" ||
+		!a.HasMarker(xmind.MarkerPriority1) || !a.HasMarker(xmind.MarkerTaskDone) {
+		t.Fatalf("topic: %+v", a)
+	}
+	if a.Notes.Plain.Content != "bold text" || a.Notes.Markdown() != "**bold** text" {
+		t.Fatalf("notes: %+v", a.Notes)
+	}
+	if !strings.HasPrefix(a.Image.Path(), xmind.Resources+"/") || string(a.Image.Data()) != "img" {
+		t.Fatalf("image: %+v", a.Image)
+	}
+	if len(wb.Entries()) != 0 {
+		t.Fatalf("entries: %v", wb.Entries())
+	}
+
+	// 转换为 xmind zen 格式后保留样式
+	buf, _ := saveZip(t, wb)
+	wb, err = xmind.LoadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cent = wb.Topics[0].On()
+	if cent.Style.Properties.Fill != "#ff0000" || string(cent.Children.Attached[0].Image.Data()) != "img" {
+		t.Fatalf("zen: %+v", cent)
+	}
+}
+
+// go test -v -run TestDiff
+func TestDiff(t *testing.T) {
+	st := xmind.NewSheet("sheet1", "main topic")
+	st.Add("Backend").Add("Frontend").Add("Docs")
+	st.OnTitle("Backend").Add("API").Add("DB")
+	st.OnTitle("Frontend").Add("Old")
+	buf, _ := saveZip(t, &xmind.WorkBook{Topics: []*xmind.Topic{st}})
+
+	a, err := xmind.LoadFrom(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := xmind.LoadFrom(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs := xmind.Diff(a, b); len(cs) != 0 {
+		t.Fatalf("same workbook: %s", cs)
+	}
+
+	sb := b.Topics[0].On()
+	sb.Move(sb.CId("DB")) // 移动到中心主题
+	api := sb.OnTitle("API")
+	api.Title = "APIs"
+	api.AddLabel("v2")
+	sb.Remove("Old")
+	sb.OnTitle("Docs").Add("README")
+
+	want := `~ [sheet1] /main topic/Backend/APIs: "API" -> "APIs"
+* [sheet1] /main topic/Backend/APIs: labels
++ [sheet1] /main topic/Docs/README
+> [sheet1] /main topic/Backend/DB -> [sheet1] /main topic/DB
+- [sheet1] /main topic/Frontend/Old
+`
+	cs := xmind.Diff(a, b)
+	if cs.String() != want {
+		t.Fatalf("diff:\n%s", cs)
+	}
+	if cs[3].Kind != xmind.ChangeMoved || cs[3].Old.ID != cs[3].New.ID || cs[4].New != nil {
+		t.Fatalf("changes: %+v", cs)
+	}
+
+	// 同一父主题下调整顺序,其他兄弟主题的下标变化不算重新排序
+	sb.OnTitle("Backend").Move(sb.CId("Docs"), xmind.BeforeMode) // Docs,Backend,Frontend,DB
+	cs = xmind.Diff(a, b)
+	if cs[0].Kind != xmind.ChangeReordered || cs[0].Path != "/main topic/Docs" ||
+		!strings.HasPrefix(cs.String(), "^ [sheet1] /main topic/Docs: 2 -> 0\n") ||
+		strings.Count(cs.String(), "^ ") != 1 {
+		t.Fatalf("reorder:\n%s", cs)
+	}
+
+	// 非标准格式的ID加载时会重新生成,只能按照路径匹配
+	content := `[{"id":"s1","title":"sheet1","rootTopic":{"id":"c1","title":"main topic","children":{"attached":[
+{"id":"t1","title":"Overview","children":{"attached":[{"id":"t2","title":"Overview"}]}},{"id":"t3","title":"Overview"}]}}}]`
+	a, err = xmind.LoadFrom(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err = xmind.LoadFrom(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Topics[0].On().ID == b.Topics[0].On().ID {
+		t.Fatal("id should be regenerated")
+	}
+	if cs = xmind.Diff(a, b); len(cs) != 0 {
+		t.Fatalf("regenerated id: %s", cs)
+	}
+}
@@ -0,0 +1,244 @@
+package xmind
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+const (
+	ExtensionTask = "org.xmind.ui.task" // 任务信息扩展,内容参考 TaskInfo
+	ExtensionMath = "org.xmind.ui.math" // 数学公式扩展,内容为LaTeX源码
+)
+
+type (
+	// Extension 主题扩展信息,Content 的格式由 Provider 决定
+	Extension struct {
+		Provider string          `json:"provider"`
+		Content  json.RawMessage `json:"content,omitempty"`
+
+		extra map[string]json.RawMessage // 未定义的字段,例如: resourceRefs,保存时原样写回
+	}
+
+	// TaskInfo 任务信息,保存在 ExtensionTask 扩展中
+	TaskInfo struct {
+		Progress     float64       // 进度,取值范围 0~1
+		Priority     int           // 优先级,取值范围 1~9,0表示没有优先级
+		Start        time.Time     // 开始时间,零值表示没有
+		Due          time.Time     // 截止时间,零值表示没有
+		Duration     time.Duration // 工期,精确到毫秒
+		Assignee     string        // 负责人
+		Dependencies []TopicID     // 依赖的任务主题ID
+
+		extra map[string]json.RawMessage // 未定义的字段,保存时原样写回
+	}
+
+	// 任务信息在json中的格式,时间使用毫秒时间戳
+	taskJSON struct {
+		Progress     float64   `json:"progress,omitempty"`
+		Priority     int       `json:"priority,omitempty"`
+		StartDate    int64     `json:"startDate,omitempty"`
+		DueDate      int64     `json:"dueDate,omitempty"`
+		Duration     int64     `json:"duration,omitempty"`
+		Assignee     string    `json:"assignee,omitempty"`
+		Dependencies []TopicID `json:"dependencies,omitempty"`
+	}
+
+	// 数学公式在json中的格式
+	mathJSON struct {
+		Content string `json:"content"` // LaTeX源码
+	}
+)
+
+var (
+	extensionFields = jsonFields(reflect.TypeOf(Extension{}))
+	taskFields      = jsonFields(reflect.TypeOf(taskJSON{}))
+	mathFields      = jsonFields(reflect.TypeOf(mathJSON{}))
+)
+
+func (e Extension) MarshalJSON() ([]byte, error) {
+	type extension Extension // 避免递归调用
+	data, err := json.Marshal(extension(e))
+	if err != nil {
+		return nil, err
+	}
+	return marshalExtra(data, e.extra)
+}
+
+func (e *Extension) UnmarshalJSON(data []byte) error {
+	type extension Extension
+	extra, err := unmarshalExtra(data, (*extension)(e), extensionFields)
+	if err != nil {
+		return err
+	}
+	e.extra = extra
+	return nil
+}
+
+// 返回当前主题指定的扩展信息,不存在时返回nil
+func (st *Topic) extension(provider string) *Extension {
+	if st == nil {
+		return nil
+	}
+	for _, e := range st.Extensions {
+		if e.Provider == provider {
+			return e
+		}
+	}
+	return nil
+}
+
+// 设置当前主题的扩展信息,content为空时删除该扩展
+func (st *Topic) setExtension(provider string, content json.RawMessage) {
+	for i, e := range st.Extensions {
+		if e.Provider == provider {
+			if len(content) == 0 {
+				st.Extensions = append(st.Extensions[:i], st.Extensions[i+1:]...)
+			} else {
+				e.Content = content
+			}
+			return
+		}
+	}
+	if len(content) > 0 {
+		st.Extensions = append(st.Extensions, &Extension{Provider: provider, Content: content})
+	}
+}
+
+// 毫秒时间戳和时间互相转换,零值对应0,转换得到的时间使用UTC时区,结果不依赖本地时区
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+func fromUnixMilli(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms).UTC()
+}
+
+func (ti TaskInfo) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(taskJSON{
+		Progress:     ti.Progress,
+		Priority:     ti.Priority,
+		StartDate:    unixMilli(ti.Start),
+		DueDate:      unixMilli(ti.Due),
+		Duration:     ti.Duration.Milliseconds(),
+		Assignee:     ti.Assignee,
+		Dependencies: ti.Dependencies,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return marshalExtra(data, ti.extra)
+}
+
+func (ti *TaskInfo) UnmarshalJSON(data []byte) error {
+	var tj taskJSON
+	extra, err := unmarshalExtra(data, &tj, taskFields)
+	if err != nil {
+		return err
+	}
+
+	*ti = TaskInfo{
+		Progress:     tj.Progress,
+		Priority:     tj.Priority,
+		Start:        fromUnixMilli(tj.StartDate),
+		Due:          fromUnixMilli(tj.DueDate),
+		Duration:     time.Duration(tj.Duration) * time.Millisecond,
+		Assignee:     tj.Assignee,
+		Dependencies: tj.Dependencies,
+		extra:        extra,
+	}
+	return nil
+}
+
+// SetTask 设置当前主题的任务信息
+//
+//	param
+//		task: 任务信息,为nil时删除任务信息
+//	return
+//		*Topic: 当前主题地址
+//
+// 修改已有任务信息时,先通过 Task 获取,修改后再调用该方法,可以保留未定义的字段
+func (st *Topic) SetTask(task *TaskInfo) *Topic {
+	if st == nil {
+		return st
+	}
+
+	var content json.RawMessage
+	if task != nil {
+		data, err := json.Marshal(task)
+		if err != nil {
+			return st
+		}
+		content = data
+	}
+	st.setExtension(ExtensionTask, content)
+	return st
+}
+
+// Task 返回当前主题任务信息的副本,没有任务信息时返回nil
+func (st *Topic) Task() *TaskInfo {
+	e := st.extension(ExtensionTask)
+	if e == nil {
+		return nil
+	}
+
+	var task TaskInfo
+	if json.Unmarshal(e.Content, &task) != nil {
+		return nil
+	}
+	return &task
+}
+
+// SetEquation 设置当前主题的数学公式
+//
+//	param
+//		latex: LaTeX源码,例如: E=mc^2,为空时删除公式
+//	return
+//		*Topic: 当前主题地址
+func (st *Topic) SetEquation(latex string) *Topic {
+	if st == nil {
+		return st
+	}
+	if latex == "" {
+		st.setExtension(ExtensionMath, nil)
+		return st
+	}
+
+	var (
+		math  mathJSON
+		extra map[string]json.RawMessage
+	)
+	if e := st.extension(ExtensionMath); e != nil {
+		extra, _ = unmarshalExtra(e.Content, &math, mathFields) // 保留未定义的字段
+	}
+	math.Content = latex
+
+	data, err := json.Marshal(math)
+	if err == nil {
+		data, err = marshalExtra(data, extra)
+	}
+	if err == nil {
+		st.setExtension(ExtensionMath, data)
+	}
+	return st
+}
+
+// Equation 返回当前主题数学公式的LaTeX源码,没有公式时返回空
+func (st *Topic) Equation() string {
+	e := st.extension(ExtensionMath)
+	if e == nil {
+		return ""
+	}
+
+	var math mathJSON
+	if json.Unmarshal(e.Content, &math) != nil {
+		return ""
+	}
+	return math.Content
+}
@@ -0,0 +1,254 @@
+package xmind
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// 返回结构体所有json字段名,用于区分未定义的字段
+func jsonFields(t reflect.Type) map[string]bool {
+	fields := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("json")
+		if name, _, _ := strings.Cut(tag, ","); name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
+
+// 解析json对象到v中,fields之外的字段原样返回,保存时再写回去
+func unmarshalExtra(data []byte, v any, fields map[string]bool) (map[string]json.RawMessage, error) {
+	err := json.Unmarshal(data, v)
+	if err != nil {
+		return nil, err
+	}
+
+	var extra map[string]json.RawMessage
+	err = json.Unmarshal(data, &extra)
+	if err != nil {
+		return nil, err
+	}
+	for k := range extra {
+		if fields[k] {
+			delete(extra, k)
+		}
+	}
+	if len(extra) == 0 {
+		return nil, nil
+	}
+	return extra, nil
+}
+
+// 将extra中的字段追加到json对象末尾,字段按名称排序保证结果稳定
+func marshalExtra(data []byte, extra map[string]json.RawMessage) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	if len(extra) == 0 || len(data) < 2 || data[len(data)-1] != '}' {
+		return data, nil
+	}
+
+	keys := make([]string, 0, len(extra))
+	for k := range extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	for i, k := range keys {
+		if i > 0 || len(bytes.TrimSpace(data[1:len(data)-1])) > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(extra[k])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+var topicFields = jsonFields(reflect.TypeOf(Topic{}))
+
+func (st *Topic) MarshalJSON() ([]byte, error) {
+	type topic Topic // 避免递归调用
+	aux := struct {
+		*topic
+		Style *Style `json:"style,omitempty"` // 空样式不写入
+	}{topic: (*topic)(st)}
+	if !st.Style.IsZero() {
+		aux.Style = &st.Style
+	}
+
+	data, err := json.Marshal(aux)
+	if err != nil {
+		return nil, err
+	}
+	return marshalExtra(data, st.extra)
+}
+
+func (st *Topic) UnmarshalJSON(data []byte) error {
+	type topic Topic
+	extra, err := unmarshalExtra(data, (*topic)(st), topicFields)
+	if err != nil {
+		return err
+	}
+	st.extra = extra
+	return nil
+}
+
+// Extra 返回未定义字段的原始json数据,不存在时返回nil
+//
+//	例如画布主题的 theme,topicPositioning 等字段
+func (st *Topic) Extra(key string) json.RawMessage {
+	if st == nil {
+		return nil
+	}
+	return st.extra[key]
+}
+
+// SetExtra 设置未定义字段的原始json数据,value为空时删除该字段
+//
+//	param
+//		key: 字段名,不能是 Topic 已定义的字段
+//		value: 合法的json数据
+//	return
+//		*Topic: 当前主题地址
+func (st *Topic) SetExtra(key string, value json.RawMessage) *Topic {
+	if topicFields[key] {
+		return st // 已定义的字段直接修改对应属性即可
+	}
+
+	if len(value) == 0 {
+		delete(st.extra, key)
+	} else {
+		if st.extra == nil {
+			st.extra = make(map[string]json.RawMessage)
+		}
+		st.extra[key] = value
+	}
+	return st
+}
+
+// 下面为主题中嵌套的对象保留未定义的字段,例如联系的 controlPoints,外框的 class
+
+var (
+	styleFields        = jsonFields(reflect.TypeOf(Style{}))
+	childrenFields     = jsonFields(reflect.TypeOf(Children{}))
+	summaryFields      = jsonFields(reflect.TypeOf(Summary{}))
+	relationshipFields = jsonFields(reflect.TypeOf(Relationship{}))
+	boundaryFields     = jsonFields(reflect.TypeOf(Boundary{}))
+	imageFields        = jsonFields(reflect.TypeOf(Image{}))
+	notesFields        = jsonFields(reflect.TypeOf(Notes{}))
+)
+
+func (s Style) MarshalJSON() ([]byte, error) {
+	type style Style // 避免递归调用
+	data, err := json.Marshal(style(s))
+	if err != nil {
+		return nil, err
+	}
+	return marshalExtra(data, s.extra)
+}
+
+func (s *Style) UnmarshalJSON(data []byte) (err error) {
+	type style Style
+	s.extra, err = unmarshalExtra(data, (*style)(s), styleFields)
+	return
+}
+
+func (c Children) MarshalJSON() ([]byte, error) {
+	type children Children
+	data, err := json.Marshal(children(c))
+	if err != nil {
+		return nil, err
+	}
+	return marshalExtra(data, c.extra)
+}
+
+func (c *Children) UnmarshalJSON(data []byte) (err error) {
+	type children Children
+	c.extra, err = unmarshalExtra(data, (*children)(c), childrenFields)
+	return
+}
+
+func (sm Summary) MarshalJSON() ([]byte, error) {
+	type summary Summary
+	data, err := json.Marshal(summary(sm))
+	if err != nil {
+		return nil, err
+	}
+	return marshalExtra(data, sm.extra)
+}
+
+func (sm *Summary) UnmarshalJSON(data []byte) (err error) {
+	type summary Summary
+	sm.extra, err = unmarshalExtra(data, (*summary)(sm), summaryFields)
+	return
+}
+
+func (r Relationship) MarshalJSON() ([]byte, error) {
+	type relationship Relationship
+	data, err := json.Marshal(relationship(r))
+	if err != nil {
+		return nil, err
+	}
+	return marshalExtra(data, r.extra)
+}
+
+func (r *Relationship) UnmarshalJSON(data []byte) (err error) {
+	type relationship Relationship
+	r.extra, err = unmarshalExtra(data, (*relationship)(r), relationshipFields)
+	return
+}
+
+func (b Boundary) MarshalJSON() ([]byte, error) {
+	type boundary Boundary
+	data, err := json.Marshal(boundary(b))
+	if err != nil {
+		return nil, err
+	}
+	return marshalExtra(data, b.extra)
+}
+
+func (b *Boundary) UnmarshalJSON(data []byte) (err error) {
+	type boundary Boundary
+	b.extra, err = unmarshalExtra(data, (*boundary)(b), boundaryFields)
+	return
+}
+
+func (img Image) MarshalJSON() ([]byte, error) {
+	type image Image
+	data, err := json.Marshal(image(img))
+	if err != nil {
+		return nil, err
+	}
+	return marshalExtra(data, img.extra)
+}
+
+func (img *Image) UnmarshalJSON(data []byte) (err error) {
+	type image Image
+	img.extra, err = unmarshalExtra(data, (*image)(img), imageFields)
+	return
+}
+
+func (n Notes) MarshalJSON() ([]byte, error) {
+	type notes Notes
+	data, err := json.Marshal(notes(n))
+	if err != nil {
+		return nil, err
+	}
+	return marshalExtra(data, n.extra)
+}
+
+func (n *Notes) UnmarshalJSON(data []byte) (err error) {
+	type notes Notes
+	n.extra, err = unmarshalExtra(data, (*notes)(n), notesFields)
+	return
+}
@@ -0,0 +1,146 @@
+package xmind
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// SheetTopic WorkBook 中查找到的主题,以及该主题所在的画布
+type SheetTopic struct {
+	Index int    // 画布在 WorkBook.Topics 中的下标
+	Sheet *Topic // 画布主题,画布名称为 Sheet.Title
+	Topic *Topic // 匹配的主题
+}
+
+// 找到第一个匹配主题后终止遍历
+var errFound = errors.New("found")
+
+// Find 从当前主题开始递归查找所有满足条件的主题,包括当前主题
+//
+//	param
+//		match: 匹配条件,可以使用 MatchTitle 等方法生成,多个条件使用 MatchAll,MatchAny 组合
+//	return
+//		[]*Topic: 所有匹配的主题,按照主题在画布中的顺序排列
+func (st *Topic) Find(match func(*Topic) bool) (res []*Topic) {
+	_ = st.Range(func(_ int, tp *Topic) error {
+		if match(tp) {
+			res = append(res, tp)
+		}
+		return nil
+	})
+	return
+}
+
+// FindFirst 同 Find,只返回第一个满足条件的主题,找不到时返回nil
+func (st *Topic) FindFirst(match func(*Topic) bool) (res *Topic) {
+	_ = st.Range(func(_ int, tp *Topic) error {
+		if match(tp) {
+			res = tp
+			return errFound
+		}
+		return nil
+	})
+	return
+}
+
+// Find 在所有画布中查找满足条件的主题,match 参考 Topic.Find
+func (wk *WorkBook) Find(match func(*Topic) bool) (res []SheetTopic) {
+	if wk == nil {
+		return nil
+	}
+	for i, topic := range wk.Topics {
+		sheet := sheetOf(topic)
+		for _, tp := range sheet.Find(match) {
+			res = append(res, SheetTopic{Index: i, Sheet: sheet, Topic: tp})
+		}
+	}
+	return
+}
+
+// FindFirst 同 WorkBook.Find,只返回第一个满足条件的主题,找不到时返回nil
+func (wk *WorkBook) FindFirst(match func(*Topic) bool) *SheetTopic {
+	if wk == nil {
+		return nil
+	}
+	for i, topic := range wk.Topics {
+		sheet := sheetOf(topic)
+		if tp := sheet.FindFirst(match); tp != nil {
+			return &SheetTopic{Index: i, Sheet: sheet, Topic: tp}
+		}
+	}
+	return nil
+}
+
+// 返回主题所在的画布主题,和 On(rootKey) 不同,不会修改最后编辑的主题
+func sheetOf(tp *Topic) *Topic {
+	if sheet := tp.Sheet(); sheet != nil {
+		return sheet
+	}
+	return tp
+}
+
+// MatchTitle 匹配主题内容满足正则表达式的主题
+func MatchTitle(re *regexp.Regexp) func(*Topic) bool {
+	return func(tp *Topic) bool { return re.MatchString(tp.Title) }
+}
+
+// MatchNotes 匹配纯文本备注包含substr的主题
+func MatchNotes(substr string) func(*Topic) bool {
+	return func(tp *Topic) bool {
+		return tp.Notes != nil && strings.Contains(tp.Notes.Plain.Content, substr)
+	}
+}
+
+// MatchLabel 匹配有指定标签的主题,label为空时匹配有任意标签的主题
+func MatchLabel(label string) func(*Topic) bool {
+	return func(tp *Topic) bool {
+		if label == "" {
+			return len(tp.Labels) > 0
+		}
+		return contains(tp.Labels, label)
+	}
+}
+
+// MatchHrefScheme 匹配链接为指定协议的主题,不区分大小写
+//
+//	param
+//		scheme: 链接协议,例如: https,xap(附件),xmind(主题链接),file
+func MatchHrefScheme(scheme string) func(*Topic) bool {
+	return func(tp *Topic) bool {
+		i := strings.IndexByte(tp.Href, ':')
+		return i > 0 && strings.EqualFold(tp.Href[:i], scheme)
+	}
+}
+
+// MatchDepth 匹配层级在[min,max]范围内的主题,中心主题为1,max<=0时不限制最大层级
+func MatchDepth(min, max int) func(*Topic) bool {
+	return func(tp *Topic) bool {
+		depth := tp.depth()
+		return depth >= min && (max <= 0 || depth <= max)
+	}
+}
+
+// MatchAll 匹配满足所有条件的主题
+func MatchAll(matches ...func(*Topic) bool) func(*Topic) bool {
+	return func(tp *Topic) bool {
+		for _, match := range matches {
+			if !match(tp) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// MatchAny 匹配满足任意一个条件的主题
+func MatchAny(matches ...func(*Topic) bool) func(*Topic) bool {
+	return func(tp *Topic) bool {
+		for _, match := range matches {
+			if match(tp) {
+				return true
+			}
+		}
+		return false
+	}
+}
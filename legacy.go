@@ -0,0 +1,828 @@
+package xmind
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//goland:noinspection SpellCheckingInspection
+const (
+	ManifestXml = "META-INF/manifest.xml" // xmind8 记录压缩包中所有文件
+	StylesXml   = "styles.xml"            // xmind8 保存所有样式,主题通过 style-id 引用
+	MetaXml     = "meta.xml"              // xmind8 记录创建者等信息
+	Attachments = "attachments"           // xmind8 保存图片和附件的目录
+
+	legacyHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n"
+
+	xmlnsContent  = "urn:xmind:xmap:xmlns:content:2.0"
+	xmlnsStyle    = "urn:xmind:xmap:xmlns:style:2.0"
+	xmlnsMeta     = "urn:xmind:xmap:xmlns:meta:2.0"
+	xmlnsManifest = "urn:xmind:xmap:xmlns:manifest:1.0"
+	xmlnsFo       = "http://www.w3.org/1999/XSL/Format"
+	xmlnsSvg      = "http://www.w3.org/2000/svg"
+	xmlnsXhtml    = "http://www.w3.org/1999/xhtml"
+	xmlnsXlink    = "http://www.w3.org/1999/xlink"
+)
+
+// 下面的结构只用于生成xmind8的xml文件,和解析用的结构区分开,避免影响json格式
+type (
+	legacyContent struct {
+		XMLName xml.Name       `xml:"xmap-content"`
+		Xmlns   string         `xml:"xmlns,attr"`
+		Fo      string         `xml:"xmlns:fo,attr"`
+		Svg     string         `xml:"xmlns:svg,attr"`
+		Xhtml   string         `xml:"xmlns:xhtml,attr"`
+		Xlink   string         `xml:"xmlns:xlink,attr"`
+		Version string         `xml:"version,attr"`
+		Sheets  []*legacySheet `xml:"sheet"`
+	}
+
+	legacySheet struct {
+		ID            TopicID              `xml:"id,attr"`
+		Topic         *legacyTopic         `xml:"topic"`
+		Title         string               `xml:"title"`
+		Relationships *legacyRelationships `xml:"relationships"`
+	}
+
+	legacyTopic struct {
+		ID             TopicID           `xml:"id,attr"`
+		StructureClass StructureClass    `xml:"structure-class,attr,omitempty"`
+		StyleID        TopicID           `xml:"style-id,attr,omitempty"`
+		Branch         string            `xml:"branch,attr,omitempty"`
+		Href           string            `xml:"xlink:href,attr,omitempty"`
+		Title          string            `xml:"title"`
+		Position       *legacyPosition   `xml:"position"`
+		Image          *legacyImage      `xml:"xhtml:img"`
+		Children       *legacyChildren   `xml:"children"`
+		Markers        *legacyMarkers    `xml:"marker-refs"`
+		Labels         *legacyLabels     `xml:"labels"`
+		Notes          *legacyNotes      `xml:"notes"`
+		Boundaries     *legacyBoundaries `xml:"boundaries"`
+		Summaries      *legacySummaries  `xml:"summaries"`
+	}
+
+	// xml的 a>b 写法在切片为空时仍会生成父节点,因此用指针包装,为空时不生成
+	legacyChildren struct {
+		Topics []*legacyTopics `xml:"topics"`
+	}
+	legacyMarkers struct {
+		Markers []Marker `xml:"marker-ref"`
+	}
+	legacyLabels struct {
+		Labels []string `xml:"label"`
+	}
+	legacyBoundaries struct {
+		Boundaries []*legacyBoundary `xml:"boundary"`
+	}
+	legacySummaries struct {
+		Summaries []*Summary `xml:"summary"`
+	}
+	legacyRelationships struct {
+		Relationships []*legacyRelationship `xml:"relationship"`
+	}
+
+	legacyTopics struct {
+		Type   TopicType      `xml:"type,attr"`
+		Topics []*legacyTopic `xml:"topic"`
+	}
+
+	legacyPosition struct {
+		X float64 `xml:"svg:x,attr"`
+		Y float64 `xml:"svg:y,attr"`
+	}
+
+	legacyImage struct {
+		Src    string `xml:"xhtml:src,attr"`
+		Width  int    `xml:"svg:width,attr,omitempty"`
+		Height int    `xml:"svg:height,attr,omitempty"`
+	}
+
+	legacyNotes struct {
+		Plain string          `xml:"plain"`
+		HTML  *legacyInnerXML `xml:"html"`
+	}
+
+	legacyInnerXML struct {
+		Inner string `xml:",innerxml"`
+	}
+
+	legacyBoundary struct {
+		ID      TopicID `xml:"id,attr"`
+		Range   string  `xml:"range,attr"`
+		StyleID TopicID `xml:"style-id,attr,omitempty"`
+		Title   string  `xml:"title,omitempty"`
+	}
+
+	legacyRelationship struct {
+		ID      TopicID `xml:"id,attr"`
+		End1ID  TopicID `xml:"end1,attr"`
+		End2ID  TopicID `xml:"end2,attr"`
+		StyleID TopicID `xml:"style-id,attr,omitempty"`
+		Title   string  `xml:"title,omitempty"`
+	}
+
+	legacyStyles struct {
+		XMLName xml.Name       `xml:"xmap-styles"`
+		Xmlns   string         `xml:"xmlns,attr"`
+		Fo      string         `xml:"xmlns:fo,attr"`
+		Svg     string         `xml:"xmlns:svg,attr"`
+		Version string         `xml:"version,attr"`
+		Styles  []*legacyStyle `xml:"styles>style"`
+	}
+
+	legacyStyle struct {
+		ID         TopicID          `xml:"id,attr"`
+		Type       string           `xml:"type,attr"`
+		Properties legacyProperties // 例如: <topic-properties svg:fill="#ff0000"/>
+	}
+
+	legacyProperties struct {
+		XMLName xml.Name
+		Attrs   []xml.Attr `xml:",any,attr"`
+	}
+
+	legacyMeta struct {
+		XMLName xml.Name `xml:"meta"`
+		Xmlns   string   `xml:"xmlns,attr"`
+		Version string   `xml:"version,attr"`
+		Creator struct {
+			Name    string `xml:"Name"`
+			Version string `xml:"Version"`
+		} `xml:"Creator"`
+	}
+
+	legacyManifest struct {
+		XMLName xml.Name          `xml:"manifest"`
+		Xmlns   string            `xml:"xmlns,attr"`
+		Entries []legacyFileEntry `xml:"file-entry"`
+	}
+
+	legacyFileEntry struct {
+		FullPath  string `xml:"full-path,attr"`
+		MediaType string `xml:"media-type,attr"`
+	}
+)
+
+// xmind8 不支持的结构,转换为效果相近的结构
+var legacyStructures = map[StructureClass]StructureClass{
+	StructMapClockwise:      StructMap,
+	StructMapAnticlockwise:  StructMap,
+	StructSpreadsheetColumn: StructSpreadsheet,
+}
+
+// 生成xmind8文件时的转换状态
+type legacyWriter struct {
+	styles []*legacyStyle
+	files  map[string][]byte // 需要写入 attachments 目录的图片和附件
+}
+
+// 将压缩包内部文件路径转换为xmind8的路径,例如: xap:resources/a.png => xap:attachments/a.png
+func (lw *legacyWriter) file(src string, data []byte) string {
+	if !strings.HasPrefix(src, xapPrefix) {
+		return src
+	}
+
+	name := Attachments + "/" + path.Base(strings.TrimPrefix(src, xapPrefix))
+	if data != nil {
+		lw.files[name] = data
+	}
+	return xapPrefix + name
+}
+
+// 记录样式,返回样式ID,空样式返回空
+func (lw *legacyWriter) style(s *Style, typ string) TopicID {
+	if s == nil || s.IsZero() {
+		return ""
+	}
+
+	data, err := json.Marshal(s.Properties)
+	if err != nil {
+		return ""
+	}
+	var props map[string]json.RawMessage
+	if json.Unmarshal(data, &props) != nil || len(props) == 0 {
+		return ""
+	}
+
+	ls := &legacyStyle{ID: s.Id, Type: typ}
+	if ls.ID == "" {
+		ls.ID = GetId()
+	}
+	if s.Type != "" {
+		ls.Type = s.Type
+	}
+	ls.Properties.XMLName.Local = ls.Type + "-properties"
+
+	for k, v := range props {
+		var value string
+		if json.Unmarshal(v, &value) != nil {
+			value = string(v) // 数字等非字符串属性直接使用原始值
+		}
+		ls.Properties.Attrs = append(ls.Properties.Attrs,
+			xml.Attr{Name: xml.Name{Local: k}, Value: value})
+	}
+	sort.Slice(ls.Properties.Attrs, func(i, j int) bool {
+		return ls.Properties.Attrs[i].Name.Local < ls.Properties.Attrs[j].Name.Local
+	})
+	lw.styles = append(lw.styles, ls)
+	return ls.ID
+}
+
+// 将主题及其子主题转换为xmind8的结构
+func (lw *legacyWriter) topic(st *Topic) *legacyTopic {
+	lt := &legacyTopic{
+		ID:             st.ID,
+		StructureClass: st.StructureClass,
+		StyleID:        lw.style(&st.Style, styleTypeTopic),
+		Branch:         st.Branch,
+		Href:           lw.file(st.Href, st.attach),
+		Title:          st.Title,
+	}
+	if len(st.Markers) > 0 {
+		lt.Markers = &legacyMarkers{Markers: st.Markers}
+	}
+	if len(st.Labels) > 0 {
+		lt.Labels = &legacyLabels{Labels: st.Labels}
+	}
+	if len(st.Summaries) > 0 {
+		lt.Summaries = &legacySummaries{Summaries: st.Summaries}
+	}
+	if sc, ok := legacyStructures[lt.StructureClass]; ok {
+		lt.StructureClass = sc
+	}
+	if st.Position != nil {
+		lt.Position = &legacyPosition{X: st.Position.X, Y: st.Position.Y}
+	}
+	if st.Image != nil {
+		lt.Image = &legacyImage{
+			Src:    lw.file(st.Image.Src, st.Image.data),
+			Width:  st.Image.Width,
+			Height: st.Image.Height,
+		}
+	}
+	if st.Notes != nil {
+		lt.Notes = &legacyNotes{Plain: st.Notes.Plain.Content}
+		if rich := st.Notes.RichContent(); rich != "" {
+			lt.Notes.HTML = &legacyInnerXML{Inner: legacyNotesHTML(rich)}
+		}
+	}
+	if len(st.Boundaries) > 0 {
+		lt.Boundaries = &legacyBoundaries{}
+	}
+	for _, b := range st.Boundaries {
+		lt.Boundaries.Boundaries = append(lt.Boundaries.Boundaries, &legacyBoundary{
+			ID:      b.ID,
+			Range:   b.Range,
+			StyleID: lw.style(b.Style, "boundary"),
+			Title:   b.Title,
+		})
+	}
+
+	if st.Children != nil {
+		for _, c := range []struct {
+			typ    TopicType
+			topics []*Topic
+		}{
+			{TopicAttached, st.Children.Attached},
+			{TopicDetached, st.Children.Detached},
+			{TopicSummary, st.Children.Summary},
+			{TopicCallout, st.Children.Callout},
+		} {
+			if len(c.topics) == 0 {
+				continue
+			}
+			ts := &legacyTopics{Type: c.typ}
+			for _, tp := range c.topics {
+				ts.Topics = append(ts.Topics, lw.topic(tp))
+			}
+			if lt.Children == nil {
+				lt.Children = &legacyChildren{}
+			}
+			lt.Children.Topics = append(lt.Children.Topics, ts)
+		}
+	}
+	return lt
+}
+
+// 将富文本备注转换为xmind8的xhtml格式,只保留段落,换行,粗体,斜体和链接
+func legacyNotesHTML(src string) string {
+	d := xml.NewDecoder(strings.NewReader("<body>" + src + "</body>"))
+	d.Strict, d.AutoClose, d.Entity = false, xml.HTMLAutoClose, xml.HTMLEntity
+
+	var (
+		sb    strings.Builder
+		stack [][2]string // 原始标签名称和对应的xhtml标签名称
+		lists []int       // 列表嵌套,-1表示无序列表,>=0表示有序列表当前序号
+		para  int         // 当前段落嵌套层数,xmind8的段落不能嵌套
+	)
+	for {
+		tk, err := d.Token()
+		if err != nil {
+			break
+		}
+
+		switch t := tk.(type) {
+		case xml.StartElement:
+			name, tag := strings.ToLower(t.Name.Local), ""
+			switch name {
+			case "p", "div", "li", "h1", "h2", "h3", "h4", "h5", "h6":
+				if para == 0 {
+					tag = "xhtml:p"
+					sb.WriteString("<xhtml:p>")
+				}
+				para++
+				if name == "li" && len(lists) > 0 {
+					if n := len(lists) - 1; lists[n] >= 0 {
+						lists[n]++
+						sb.WriteString(strconv.Itoa(lists[n]) + ". ")
+					} else {
+						sb.WriteString("• ")
+					}
+				}
+			case "ul":
+				lists = append(lists, -1)
+			case "ol":
+				lists = append(lists, 0)
+			case "br":
+				sb.WriteString("<xhtml:br/>")
+			case "b", "strong":
+				tag = "xhtml:span"
+				sb.WriteString(`<xhtml:span style="font-weight:bold;">`)
+			case "i", "em":
+				tag = "xhtml:span"
+				sb.WriteString(`<xhtml:span style="font-style:italic;">`)
+			case "a":
+				for _, a := range t.Attr {
+					if strings.EqualFold(a.Name.Local, "href") {
+						tag = "xhtml:a"
+						sb.WriteString(`<xhtml:a xlink:href="`)
+						_ = xml.EscapeText(&sb, []byte(a.Value))
+						sb.WriteString(`">`)
+					}
+				}
+			}
+			stack = append(stack, [2]string{name, tag})
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+			n := len(stack) - 1
+			if n < 0 || stack[n][0] != name {
+				continue // 非严格模式下可能出现不匹配的结束标签
+			}
+			switch name {
+			case "p", "div", "li", "h1", "h2", "h3", "h4", "h5", "h6":
+				para--
+			case "ul", "ol":
+				lists = lists[:len(lists)-1]
+			}
+			if stack[n][1] != "" {
+				sb.WriteString("</" + stack[n][1] + ">")
+			}
+			stack = stack[:n]
+		case xml.CharData:
+			_ = xml.EscapeText(&sb, t)
+		}
+	}
+	return sb.String()
+}
+
+func (lw *legacyWriter) sheet(sheet *Topic) *legacySheet {
+	ls := &legacySheet{
+		ID:    sheet.ID,
+		Topic: lw.topic(sheet.RootTopic),
+		Title: sheet.Title,
+	}
+	if len(sheet.Relationships) > 0 {
+		ls.Relationships = &legacyRelationships{}
+	}
+	for _, r := range sheet.Relationships {
+		ls.Relationships.Relationships = append(ls.Relationships.Relationships, &legacyRelationship{
+			ID:      r.ID,
+			End1ID:  r.End1ID,
+			End2ID:  r.End2ID,
+			StyleID: lw.style(r.Style, "relationship"),
+			Title:   r.Title,
+		})
+	}
+	return ls
+}
+
+// 按照xmind8的格式生成xml文件
+func writeLegacyXML(aw *archiveWriter, name string, v any) error {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return aw.write(name, append([]byte(legacyHeader), data...))
+}
+
+// SaveToXML 将xmind保存为xmind8可以打开的格式,写入io.Writer对象
+//
+// 压缩包包含 content.xml,styles.xml,meta.xml,META-INF/manifest.xml 以及图片和附件
+// 只写入xmind8支持的内容,加载文件时保留的其他文件以及未定义的json字段不会写入
+// opts 参考 SaveTo,xmind8格式不支持 WithPassword 加密
+func (wk *WorkBook) SaveToXML(w io.Writer, opts ...SaveOption) error {
+	err := wk.check()
+	if err != nil {
+		return err
+	}
+
+	var opt saveOption
+	for _, o := range opts {
+		o(&opt)
+	}
+	if opt.password != "" {
+		return errors.New("xmind8 format does not support password")
+	}
+
+	lw := &legacyWriter{files: make(map[string][]byte)}
+	content := &legacyContent{
+		Xmlns:   xmlnsContent,
+		Fo:      xmlnsFo,
+		Svg:     xmlnsSvg,
+		Xhtml:   xmlnsXhtml,
+		Xlink:   xmlnsXlink,
+		Version: "2.0",
+	}
+	for _, topic := range wk.Topics {
+		sheet := topic.On(rootKey)
+		if sheet == nil {
+			continue // 空画布保存为json时为null,xml中直接忽略
+		}
+		if sheet.RootTopic == nil {
+			return RootIsNull
+		}
+		content.Sheets = append(content.Sheets, lw.sheet(sheet))
+	}
+
+	aw := newArchiveWriter(w)
+	err = writeLegacyXML(aw, ContentXml, content)
+	if err != nil {
+		return err
+	}
+	err = writeLegacyXML(aw, StylesXml, &legacyStyles{
+		Xmlns:   xmlnsStyle,
+		Fo:      xmlnsFo,
+		Svg:     xmlnsSvg,
+		Version: "2.0",
+		Styles:  lw.styles,
+	})
+	if err != nil {
+		return err
+	}
+
+	meta := &legacyMeta{Xmlns: xmlnsMeta, Version: "2.0"}
+	meta.Creator.Name = DefaultCreator
+	if wk.Metadata != nil {
+		meta.Creator.Name, meta.Creator.Version = wk.Metadata.Creator.Name, wk.Metadata.Creator.Version
+	}
+	err = writeLegacyXML(aw, MetaXml, meta)
+	if err != nil {
+		return err
+	}
+
+	thumb, ok := wk.Entry(ThumbnailPath)
+	if opt.thumbnail {
+		thumb, err = renderThumbnail(wk.Topics)
+		if err != nil {
+			return err
+		}
+		ok = thumb != nil
+	}
+	if ok {
+		err = aw.write(ThumbnailPath, thumb)
+		if err != nil {
+			return err
+		}
+	}
+
+	names := make([]string, 0, len(lw.files))
+	for name := range lw.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		err = aw.write(name, lw.files[name])
+		if err != nil {
+			return err
+		}
+	}
+	return aw.closeLegacy()
+}
+
+// SaveLegacy 保存对象为xmind8可以打开的 *.xmind 文件,opts 参考 SaveTo
+func (wk *WorkBook) SaveLegacy(path string, opts ...SaveOption) error {
+	if filepath.Ext(path) != ".xmind" {
+		return fmt.Errorf("%s: suffix must be .xmind", path)
+	}
+
+	fw, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer fw.Close()
+
+	return wk.SaveToXML(fw, opts...)
+}
+
+// 写入 META-INF/manifest.xml,然后关闭压缩包
+func (aw *archiveWriter) closeLegacy() error {
+	names := make([]string, 0, len(aw.manifest.FileEntries)+1)
+	for name := range aw.manifest.FileEntries {
+		names = append(names, name)
+	}
+	names = append(names, ManifestXml)
+	sort.Strings(names)
+
+	mf := &legacyManifest{Xmlns: xmlnsManifest}
+	for _, name := range names {
+		mediaType := mime.TypeByExtension(path.Ext(name))
+		if path.Ext(name) == ".xml" {
+			mediaType = "text/xml" // 和xmind8保持一致
+		}
+		mf.Entries = append(mf.Entries, legacyFileEntry{FullPath: name, MediaType: mediaType})
+	}
+
+	data, err := xml.Marshal(mf)
+	if err != nil {
+		return err
+	}
+	w, err := aw.zw.Create(ManifestXml)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append([]byte(legacyHeader), data...))
+	if err != nil {
+		return err
+	}
+	return aw.zw.Close()
+}
+
+// UnmarshalXML 解析xmind8的主题,样式先记录 style-id,加载 styles.xml 后替换为实际样式
+func (st *Topic) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type topic Topic // 避免递归调用
+	aux := struct {
+		*topic
+		StyleID TopicID    `xml:"style-id,attr"`
+		Attrs   []xml.Attr `xml:",any,attr"` // xlink:href 的命名空间不固定,从这里读取
+		Notes   *struct {
+			Plain ContentStruct   `xml:"plain"`
+			HTML  *legacyInnerXML `xml:"html"`
+		} `xml:"notes"`
+		Boundaries []*struct {
+			*Boundary
+			StyleID TopicID `xml:"style-id,attr"`
+		} `xml:"boundaries>boundary"`
+		Relationships []*struct {
+			*Relationship
+			StyleID TopicID `xml:"style-id,attr"`
+		} `xml:"relationships>relationship"`
+	}{topic: (*topic)(st)}
+	err := d.DecodeElement(&aux, &start)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range aux.Attrs {
+		if a.Name.Local == "href" && (a.Name.Space == xmlnsXlink || a.Name.Space == "xlink") {
+			st.Href = a.Value
+		}
+	}
+	if aux.StyleID != "" {
+		st.Style = Style{Id: aux.StyleID}
+	}
+	if aux.Notes != nil {
+		st.Notes = &Notes{Plain: aux.Notes.Plain}
+		if aux.Notes.HTML != nil {
+			if rich := legacyHTMLToNotes(aux.Notes.HTML.Inner); rich != "" {
+				st.Notes.RealHTML = &ContentStruct{Content: rich}
+			}
+		}
+	}
+	for _, b := range aux.Boundaries {
+		if b.StyleID != "" {
+			b.Style = &Style{Id: b.StyleID}
+		}
+		st.Boundaries = append(st.Boundaries, b.Boundary)
+	}
+	for _, r := range aux.Relationships {
+		if r.StyleID != "" {
+			r.Style = &Style{Id: r.StyleID}
+		}
+		st.Relationships = append(st.Relationships, r.Relationship)
+	}
+	return nil
+}
+
+// 将xmind8备注中的xhtml转换为富文本备注的html,和 legacyNotesHTML 相反
+func legacyHTMLToNotes(src string) string {
+	d := xml.NewDecoder(strings.NewReader("<body>" + src + "</body>"))
+	d.Strict, d.AutoClose, d.Entity = false, xml.HTMLAutoClose, xml.HTMLEntity
+
+	var (
+		sb    strings.Builder
+		stack []string // 每个标签对应的结束标签
+	)
+	for {
+		tk, err := d.Token()
+		if err != nil {
+			break
+		}
+
+		switch t := tk.(type) {
+		case xml.StartElement:
+			end := ""
+			switch strings.ToLower(t.Name.Local) {
+			case "p", "div":
+				sb.WriteString("<p>")
+				end = "</p>"
+			case "br":
+				sb.WriteString("<br/>")
+			case "span":
+				for _, a := range t.Attr {
+					if a.Name.Local != "style" {
+						continue
+					}
+					style := strings.ReplaceAll(a.Value, " ", "")
+					if strings.Contains(style, "font-weight:bold") {
+						sb.WriteString("<strong>")
+						end = "</strong>" + end
+					}
+					if strings.Contains(style, "font-style:italic") {
+						sb.WriteString("<em>")
+						end = "</em>" + end
+					}
+				}
+			case "a":
+				for _, a := range t.Attr {
+					if a.Name.Local == "href" {
+						sb.WriteString(`<a href="`)
+						_ = xml.EscapeText(&sb, []byte(a.Value))
+						sb.WriteString(`">`)
+						end = "</a>"
+					}
+				}
+			}
+			stack = append(stack, end)
+		case xml.EndElement:
+			if n := len(stack) - 1; n >= 0 {
+				sb.WriteString(stack[n])
+				stack = stack[:n]
+			}
+		case xml.CharData:
+			_ = xml.EscapeText(&sb, t)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// 解析xmind8的 styles.xml,返回样式ID对应的样式
+//
+//	<style id="xx" type="topic"><topic-properties svg:fill="#ff0000"/></style>
+func parseLegacyStyles(r io.Reader) map[TopicID]*Style {
+	var (
+		d      = xml.NewDecoder(r)
+		styles = make(map[TopicID]*Style)
+		cur    *Style
+	)
+	for {
+		tk, err := d.Token()
+		if err != nil {
+			return styles
+		}
+
+		switch t := tk.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "style" {
+				cur = &Style{}
+				for _, a := range t.Attr {
+					switch a.Name.Local {
+					case "id":
+						cur.Id = TopicID(a.Value)
+					case "type":
+						cur.Type = a.Value
+					}
+				}
+				styles[cur.Id] = cur
+			} else if cur != nil && strings.HasSuffix(t.Name.Local, "-properties") {
+				props := make(map[string]string, len(t.Attr))
+				for _, a := range t.Attr {
+					props[legacyAttrName(a.Name)] = a.Value
+				}
+				// 通过json转换,常用属性保存到字段中,其他属性保存到 extra 中
+				if data, err := json.Marshal(props); err == nil {
+					_ = json.Unmarshal(data, &cur.Properties)
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "style" {
+				cur = nil
+			}
+		}
+	}
+}
+
+// 将解析后的属性名还原为带前缀的名称,例如: svg:fill
+func legacyAttrName(name xml.Name) string {
+	switch name.Space {
+	case "":
+		return name.Local
+	case xmlnsFo:
+		return "fo:" + name.Local
+	case xmlnsSvg:
+		return "svg:" + name.Local
+	default:
+		return name.Space + ":" + name.Local // 没有声明的前缀解析时会原样保留
+	}
+}
+
+// 将 style-id 替换为 styles 中的实际样式,找不到的样式直接删除
+func (wk *WorkBook) resolveStyles(styles map[TopicID]*Style) {
+	find := func(s *Style) *Style {
+		if s == nil || s.Id == "" {
+			return s
+		}
+		v, ok := styles[s.Id]
+		if !ok {
+			return nil
+		}
+
+		cp := *v // 多个主题引用同一个样式,需要复制一份,避免修改时互相影响
+		if v.Properties.extra != nil {
+			cp.Properties.extra = make(map[string]json.RawMessage, len(v.Properties.extra))
+			for k, raw := range v.Properties.extra {
+				cp.Properties.extra[k] = raw
+			}
+		}
+		return &cp
+	}
+	resolve := func(tp *Topic) {
+		if s := find(&tp.Style); s != nil {
+			tp.Style = *s
+		} else {
+			tp.Style = Style{}
+		}
+		for _, b := range tp.Boundaries {
+			b.Style = find(b.Style)
+		}
+	}
+
+	for _, sheet := range wk.Topics {
+		if sheet == nil {
+			continue
+		}
+		resolve(sheet)
+		for _, r := range sheet.Relationships {
+			r.Style = find(r.Style)
+		}
+		_ = sheet.Range(func(_ int, tp *Topic) error {
+			resolve(tp)
+			return nil
+		})
+	}
+}
+
+// 读取xmind8压缩包中的样式,并将 attachments 目录中的图片和附件转换到 resources 目录
+func (wk *WorkBook) loadLegacy(ar *archiveReader) {
+	var styles map[TopicID]*Style
+	if data, err := ar.read(StylesXml); err == nil {
+		styles = parseLegacyStyles(bytes.NewReader(data))
+	}
+	wk.resolveStyles(styles)
+
+	moved := make(map[string]bool)
+	move := func(src string, data []byte) string {
+		name := strings.TrimPrefix(src, xapPrefix)
+		if data == nil || name == src || !strings.HasPrefix(name, Attachments+"/") {
+			return src
+		}
+		moved[name] = true
+		return xapPrefix + resourcePath(name, data)
+	}
+	for _, sheet := range wk.Topics {
+		if sheet == nil {
+			continue
+		}
+		_ = sheet.Range(func(_ int, tp *Topic) error {
+			if tp.Image != nil {
+				tp.Image.Src = move(tp.Image.Src, tp.Image.data)
+			}
+			tp.Href = move(tp.Href, tp.attach)
+			return nil
+		})
+	}
+	for name := range moved {
+		wk.RemoveEntry(name) // 已经转换到 resources 目录,不需要保留原文件
+	}
+}
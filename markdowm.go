@@ -9,12 +9,19 @@ import (
 
 const (
 	DefaultMarkdownName   = "default"
-	DefaultMarkdownFormat = "{{Repeat \"#\" ." + MarkdownKeyDeep +
-		"}} {{." + CustomKeyTitle + "}}\n\n{{range $i,$v := ." + CustomKeyLabels +
-		"}}> {{$v}}\n\n{{end}}{{range $i,$v := (SplitLines ." + CustomKeyNotes +
-		" \"\\n\\r\")}}> {{$v}}\n\n{{end}}"
+	DefaultMarkdownFormat = "{{if eq ." + MarkdownKeyType + " \"" + string(TopicCallout) + "\"}}> [!NOTE]\n> {{." +
+		CustomKeyTitle + "}}\n\n{{else}}{{Repeat \"#\" ." + MarkdownKeyDeep +
+		"}} {{if ." + CustomKeyNumber + "}}{{." + CustomKeyNumber + "}} {{end}}{{." + CustomKeyTitle +
+		"}}\n\n{{range $i,$v := ." + CustomKeyLabels +
+		"}}> {{$v}}\n\n{{end}}{{if ." + MarkdownKeyEquation + "}}$$\n{{." + MarkdownKeyEquation +
+		"}}\n$$\n\n{{end}}{{if ." + MarkdownKeyRichNotes + "}}{{." + MarkdownKeyRichNotes +
+		"}}\n\n{{else}}{{range $i,$v := (SplitLines ." + CustomKeyNotes +
+		" \"\\n\\r\")}}> {{$v}}\n\n{{end}}{{end}}{{end}}"
 
-	MarkdownKeyDeep = "Deep" // 所在层级,>=1
+	MarkdownKeyDeep      = "Deep"      // 所在层级,>=1
+	MarkdownKeyRichNotes = "RichNotes" // 富文本备注转换后的markdown
+	MarkdownKeyType      = "Type"      // 主题类型,参考 TopicType,标注渲染为 > [!NOTE]
+	MarkdownKeyEquation  = "Equation"  // 数学公式的LaTeX源码,渲染为 $$...$$
 )
 
 func (wk *WorkBook) SaveToMarkdown(w io.Writer, format map[string]string) error {
@@ -61,20 +68,33 @@ func (wk *WorkBook) SaveToMarkdown(w io.Writer, format map[string]string) error
 		err = cent.Range(func(deep int, current *Topic) error {
 			data := map[string]any{
 				MarkdownKeyDeep: deep,
+				MarkdownKeyType: string(current.Type()),
 				CustomKeyTitle:  current.Title,
 			}
 			if len(current.Labels) > 0 {
 				data[CustomKeyLabels] = current.Labels
 			}
+			if len(current.Markers) > 0 {
+				data[CustomKeyMarkers] = current.markerIds()
+			}
 			if current.Notes != nil && current.Notes.Plain.Content != "" {
 				data[CustomKeyNotes] = current.Notes.Plain.Content
 			}
+			if rich := current.Notes.Markdown(); rich != "" {
+				data[MarkdownKeyRichNotes] = rich
+			}
 			if current.Branch != "" {
 				data[CustomKeyBranch] = current.Branch
 			}
 			if current.Href != "" {
 				data[CustomKeyHref] = current.Href
 			}
+			if num := current.Number(); num != "" {
+				data[CustomKeyNumber] = num
+			}
+			if eq := current.Equation(); eq != "" {
+				data[MarkdownKeyEquation] = eq
+			}
 
 			tw := tpl.Lookup(strconv.Itoa(deep))
 			if tw == nil {
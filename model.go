@@ -3,28 +3,39 @@ package xmind
 import (
 	"crypto/rand"
 	"encoding/base32"
+	"encoding/json"
 	"encoding/xml"
 	"strconv"
+	"strings"
 	"sync/atomic"
 )
 
 /*
 下面的结构支持从xml和json中解析xmind文件
-但只支持生成json的xmind文件,没有做直接生成xml文件的方法
+生成xmind8使用的xml文件参考 WorkBook.SaveToXML,使用单独的结构生成
 */
 //goland:noinspection SpellCheckingInspection
 type (
 	WorkBook struct {
 		XMLName xml.Name `xml:"xmap-content"`
 		Topics  []*Topic `json:"sheet" xml:"sheet"`
+
+		// Manifest 加载文件时读取的 manifest.json,保存文件时会根据实际写入的文件重新生成
+		Manifest *ManifestInfo `json:"-" xml:"-"`
+		// Metadata 加载文件时读取的 metadata.json,保存文件时写入,为空时使用默认值
+		Metadata *MetadataInfo `json:"-" xml:"-"`
+
+		entries []archiveEntry // 压缩包中其他文件,例如: Thumbnails,resources,保存时原样写回
 	}
 
 	// Topic 定义内容参考xmind官方ts实现,参考如下代码
 	// https://github.com/xmindltd/xmind-sdk-js/blob/master/src/common/model.ts#L121
 	Topic struct {
-		resources map[TopicID]*Topic // 记录所有主题的资源,所有主题共用同一个
-		parent    *Topic             // 父节点地址
-		incr      *int               // 只用于自增id,生成不重复的默认主题内容
+		resources map[TopicID]*Topic         // 记录所有主题的资源,所有主题共用同一个
+		parent    *Topic                     // 父节点地址
+		incr      *int                       // 只用于自增id,生成不重复的默认主题内容
+		attach    []byte                     // 附件数据,超链接为压缩包内部文件时有效
+		extra     map[string]json.RawMessage // 未定义的字段,例如: theme,保存时原样写回
 
 		RootTopic      *Topic         `json:"rootTopic,omitempty" xml:"topic"`
 		Children       *Children      `json:"children,omitempty" xml:"children"`
@@ -36,24 +47,102 @@ type (
 		StructureClass StructureClass `json:"structureClass,omitempty" xml:"structure-class,attr"`
 		Style          Style          `json:"style"`
 		Labels         []string       `json:"labels,omitempty" xml:"labels>label"`
+		Markers        []Marker       `json:"markers,omitempty" xml:"marker-refs>marker-ref"`
+		Image          *Image         `json:"image,omitempty" xml:"img"`
+		Position       *Position      `json:"position,omitempty" xml:"position"`
+		Summaries      []*Summary     `json:"summaries,omitempty" xml:"summaries>summary"`
+		Boundaries     []*Boundary    `json:"boundaries,omitempty" xml:"boundaries>boundary"`
+		Numbering      *Numbering     `json:"numbering,omitempty" xml:"-"`  // 子主题编号
+		Extensions     []*Extension   `json:"extensions,omitempty" xml:"-"` // 扩展信息,例如: 任务信息
+		// Relationships 联系,只有画布主题才有该字段
+		Relationships []*Relationship `json:"relationships,omitempty" xml:"relationships>relationship"`
 	}
 
 	TopicID string
 
+	// Style 样式,属性参考 StyleProperties
 	Style struct {
-		Properties any `json:"properties"`
-		Id         TopicID     `json:"id"`
-		Type       string      `json:"type"`
+		Properties StyleProperties `json:"properties"`
+		Id         TopicID         `json:"id,omitempty"`
+		Type       string          `json:"type,omitempty"`
+
+		extra map[string]json.RawMessage // 未定义的字段,保存时原样写回
 	}
 
 	Children struct {
-		Attached []*Topic `json:"attached" xml:"topics>topic"`
+		Attached []*Topic `json:"attached,omitempty" xml:"-"`
+		Detached []*Topic `json:"detached,omitempty" xml:"-"` // 自由主题
+		Summary  []*Topic `json:"summary,omitempty" xml:"-"`  // 概要主题
+		Callout  []*Topic `json:"callout,omitempty" xml:"-"`  // 标注
+
+		extra map[string]json.RawMessage // 未定义的字段,保存时原样写回
+	}
+
+	// Summary 概要,Range 表示概要包含的子主题范围,例如: (0,2)
+	Summary struct {
+		ID      TopicID `json:"id" xml:"id,attr"`
+		Range   string  `json:"range" xml:"range,attr"`
+		TopicID TopicID `json:"topicId" xml:"topic-id,attr"` // 概要主题ID
+
+		extra map[string]json.RawMessage // 未定义的字段,保存时原样写回
+	}
+
+	// Relationship 联系,连接画布中任意两个主题
+	Relationship struct {
+		ID     TopicID `json:"id" xml:"id,attr"`
+		End1ID TopicID `json:"end1Id" xml:"end1,attr"` // 起点主题ID
+		End2ID TopicID `json:"end2Id" xml:"end2,attr"` // 终点主题ID
+		Title  string  `json:"title,omitempty" xml:"title"`
+		Style  *Style  `json:"style,omitempty" xml:"-"`
+
+		extra map[string]json.RawMessage // 未定义的字段,保存时原样写回
+	}
+
+	// Boundary 外框,Range 表示外框包含的子主题范围,为 BoundaryMaster 时表示包含整个主题
+	Boundary struct {
+		ID    TopicID `json:"id" xml:"id,attr"`
+		Range string  `json:"range" xml:"range,attr"`
+		Title string  `json:"title,omitempty" xml:"title"`
+		Style *Style  `json:"style,omitempty" xml:"-"`
+
+		extra map[string]json.RawMessage // 未定义的字段,保存时原样写回
+	}
+
+	// Marker 图标,同一组图标(例如优先级)在主题上只能存在一个
+	Marker struct {
+		MarkerId MarkerID `json:"markerId" xml:"marker-id,attr"`
 	}
 
+	// MarkerID 图标ID,格式为 组名-图标名,例如: priority-1
+	MarkerID string
+
+	// Image 主题图片,图片数据保存在xmind压缩包的 resources 目录中
+	Image struct {
+		Src    string `json:"src" xml:"src,attr"` // 例如: xap:resources/xxx.png
+		Width  int    `json:"width,omitempty" xml:"width,attr"`
+		Height int    `json:"height,omitempty" xml:"height,attr"`
+
+		data  []byte                     // 图片数据,加载或添加图片时赋值,保存时写入压缩包
+		extra map[string]json.RawMessage // 未定义的字段,保存时原样写回
+	}
+
+	// Position 主题坐标,自由主题需要通过坐标确定位置
+	Position struct {
+		X float64 `json:"x" xml:"x,attr"`
+		Y float64 `json:"y" xml:"y,attr"`
+	}
+
+	// TopicType 子主题类型,和xmind中children的key一致
+	TopicType string
+
 	StructureClass string
 
 	Notes struct {
-		Plain ContentStruct `json:"plain" xml:"plain"`
+		Plain    ContentStruct   `json:"plain" xml:"plain"`
+		RealHTML *ContentStruct  `json:"realHTML,omitempty" xml:"-"` // 富文本备注,内容为html
+		HTML     json.RawMessage `json:"html,omitempty" xml:"-"`     // 旧版本富文本备注,按段落保存
+
+		extra map[string]json.RawMessage // 未定义的字段,保存时原样写回
 	}
 
 	ContentStruct struct {
@@ -61,6 +150,47 @@ type (
 	}
 )
 
+// UnmarshalXML xml中子主题按照 <topics type="attached"> 的方式区分类型
+func (c *Children) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		tk, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tk.(type) {
+		case xml.StartElement:
+			if t.Name.Local != "topics" {
+				if err = d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+
+			var topics struct {
+				Type   TopicType `xml:"type,attr"`
+				Topics []*Topic  `xml:"topic"`
+			}
+			if err = d.DecodeElement(&topics, &t); err != nil {
+				return err
+			}
+
+			switch topics.Type {
+			case TopicDetached:
+				c.Detached = append(c.Detached, topics.Topics...)
+			case TopicSummary:
+				c.Summary = append(c.Summary, topics.Topics...)
+			case TopicCallout:
+				c.Callout = append(c.Callout, topics.Topics...)
+			default:
+				c.Attached = append(c.Attached, topics.Topics...)
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
 func (cs *ContentStruct) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	return d.DecodeElement(&cs.Content, &start) // xml和json结构不兼容
 }
@@ -74,6 +204,14 @@ const (
 	Thumbnails  = "Thumbnails"
 	Resources   = "resources"
 
+	BoundaryMaster = "master" // 外框包含整个主题
+
+	TopicRoot     TopicType = "root"     // 中心主题
+	TopicAttached TopicType = "attached" // 普通子主题
+	TopicDetached TopicType = "detached" // 自由主题
+	TopicSummary  TopicType = "summary"  // 概要主题
+	TopicCallout  TopicType = "callout"  // 标注
+
 	StructMapUnbalanced       StructureClass = "org.xmind.ui.map.unbalanced"       // 思维导图
 	StructMap                 StructureClass = "org.xmind.ui.map"                  // 平衡图(向下)
 	StructMapClockwise        StructureClass = "org.xmind.ui.map.clockwise"        // 平衡图(顺时针)
@@ -92,6 +230,59 @@ const (
 	StructSpreadsheetColumn   StructureClass = "org.xmind.ui.spreadsheet.column"   // 矩阵(列)
 )
 
+//goland:noinspection GoUnusedConst,SpellCheckingInspection
+const (
+	MarkerPriority1 MarkerID = "priority-1" // 优先级1
+	MarkerPriority2 MarkerID = "priority-2" // 优先级2
+	MarkerPriority3 MarkerID = "priority-3" // 优先级3
+	MarkerPriority4 MarkerID = "priority-4" // 优先级4
+	MarkerPriority5 MarkerID = "priority-5" // 优先级5
+	MarkerPriority6 MarkerID = "priority-6" // 优先级6
+	MarkerPriority7 MarkerID = "priority-7" // 优先级7
+	MarkerPriority8 MarkerID = "priority-8" // 优先级8
+	MarkerPriority9 MarkerID = "priority-9" // 优先级9
+
+	MarkerTaskStart   MarkerID = "task-start"   // 任务进度0%
+	MarkerTaskOct     MarkerID = "task-oct"     // 任务进度1/8
+	MarkerTaskQuarter MarkerID = "task-quarter" // 任务进度1/4
+	MarkerTask3Oct    MarkerID = "task-3oct"    // 任务进度3/8
+	MarkerTaskHalf    MarkerID = "task-half"    // 任务进度1/2
+	MarkerTask5Oct    MarkerID = "task-5oct"    // 任务进度5/8
+	MarkerTask3Quar   MarkerID = "task-3quar"   // 任务进度3/4
+	MarkerTask7Oct    MarkerID = "task-7oct"    // 任务进度7/8
+	MarkerTaskDone    MarkerID = "task-done"    // 任务完成
+
+	MarkerFlagRed    MarkerID = "flag-red"    // 红旗
+	MarkerFlagOrange MarkerID = "flag-orange" // 橙旗
+	MarkerFlagYellow MarkerID = "flag-yellow" // 黄旗
+	MarkerFlagGreen  MarkerID = "flag-green"  // 绿旗
+	MarkerFlagBlue   MarkerID = "flag-blue"   // 蓝旗
+	MarkerFlagPurple MarkerID = "flag-purple" // 紫旗
+	MarkerFlagBlack  MarkerID = "flag-black"  // 黑旗
+	MarkerFlagGray   MarkerID = "flag-gray"   // 灰旗
+
+	MarkerStarRed    MarkerID = "star-red"    // 红星
+	MarkerStarOrange MarkerID = "star-orange" // 橙星
+	MarkerStarYellow MarkerID = "star-yellow" // 黄星
+	MarkerStarGreen  MarkerID = "star-green"  // 绿星
+	MarkerStarBlue   MarkerID = "star-blue"   // 蓝星
+	MarkerStarPurple MarkerID = "star-purple" // 紫星
+	MarkerStarGray   MarkerID = "star-gray"   // 灰星
+
+	MarkerSmileySmile    MarkerID = "smiley-smile"    // 微笑
+	MarkerSmileyLaugh    MarkerID = "smiley-laugh"    // 大笑
+	MarkerSmileyAngry    MarkerID = "smiley-angry"    // 生气
+	MarkerSmileyCry      MarkerID = "smiley-cry"      // 哭泣
+	MarkerSmileySurprise MarkerID = "smiley-surprise" // 惊讶
+	MarkerSmileyBoring   MarkerID = "smiley-boring"   // 无聊
+)
+
+// Group 返回图标所在分组,例如: priority-1 的分组为 priority
+func (m MarkerID) Group() string {
+	group, _, _ := strings.Cut(string(m), "-")
+	return group
+}
+
 //goland:noinspection SpellCheckingInspection
 var (
 	objectIDCounter uint32
@@ -0,0 +1,303 @@
+package xmind
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AddRichNotes 在当前主题上加富文本备注,同时生成纯文本备注
+//
+//	param
+//		notes: 以'<'开头时作为html处理,否则作为markdown处理,支持如下语法
+//		  段落: 空行分隔
+//		  列表: "- item","* item","1. item"
+//		  行内: **粗体**,*斜体*,`代码`,[链接](https://xx)
+//	return
+//		*Topic: 当前主题地址
+func (st *Topic) AddRichNotes(notes string) *Topic {
+	notes = strings.TrimSpace(notes)
+	if notes == "" {
+		return st
+	}
+
+	rich := notes
+	if !strings.HasPrefix(notes, "<") {
+		rich = markdownToHTML(notes)
+	}
+	st.Notes = &Notes{
+		Plain:    ContentStruct{Content: htmlToText(rich, false)},
+		RealHTML: &ContentStruct{Content: rich},
+	}
+	return st
+}
+
+// RichContent 返回富文本备注的html内容,没有富文本备注时返回空
+func (n *Notes) RichContent() string {
+	if n == nil {
+		return ""
+	}
+	if n.RealHTML != nil && n.RealHTML.Content != "" {
+		return n.RealHTML.Content
+	}
+	return notesHTML(n.HTML)
+}
+
+// Markdown 将富文本备注转换为markdown,没有富文本备注时返回空
+func (n *Notes) Markdown() string {
+	if rich := n.RichContent(); rich != "" {
+		return htmlToText(rich, true)
+	}
+	return ""
+}
+
+var (
+	mdOrdered = regexp.MustCompile(`^\d+[.)]\s+`)
+	mdInline  = regexp.MustCompile("\\*\\*(.+?)\\*\\*|\\*(.+?)\\*|`(.+?)`|\\[(.+?)]\\((.+?)\\)")
+	htmlSpace = regexp.MustCompile(`\s+`)
+)
+
+// 将markdown的一个子集转换为html,不支持的语法按普通文本处理
+func markdownToHTML(md string) string {
+	var (
+		sb   strings.Builder
+		list string // 当前所在列表标签,为空表示不在列表中
+		para []string
+	)
+	flushPara := func() {
+		if len(para) > 0 {
+			sb.WriteString("<p>")
+			sb.WriteString(strings.Join(para, "<br/>"))
+			sb.WriteString("</p>")
+			para = para[:0]
+		}
+	}
+	setList := func(tag string) {
+		if list != tag {
+			if list != "" {
+				sb.WriteString("</" + list + ">")
+			}
+			if tag != "" {
+				sb.WriteString("<" + tag + ">")
+			}
+			list = tag
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			flushPara()
+			setList("")
+		case strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* "):
+			flushPara()
+			setList("ul")
+			sb.WriteString("<li>" + mdInlineHTML(line[2:]) + "</li>")
+		case mdOrdered.MatchString(line):
+			flushPara()
+			setList("ol")
+			sb.WriteString("<li>" + mdInlineHTML(mdOrdered.ReplaceAllString(line, "")) + "</li>")
+		default:
+			setList("")
+			para = append(para, mdInlineHTML(line))
+		}
+	}
+	flushPara()
+	setList("")
+	return sb.String()
+}
+
+// 转换markdown行内语法
+func mdInlineHTML(s string) string {
+	var sb strings.Builder
+	last := 0
+	for _, m := range mdInline.FindAllStringSubmatchIndex(s, -1) {
+		sb.WriteString(html.EscapeString(s[last:m[0]]))
+		last = m[1]
+
+		switch {
+		case m[2] >= 0:
+			sb.WriteString("<strong>" + mdInlineHTML(s[m[2]:m[3]]) + "</strong>")
+		case m[4] >= 0:
+			sb.WriteString("<em>" + mdInlineHTML(s[m[4]:m[5]]) + "</em>")
+		case m[6] >= 0:
+			sb.WriteString("<code>" + html.EscapeString(s[m[6]:m[7]]) + "</code>")
+		default:
+			sb.WriteString(`<a href="` + html.EscapeString(s[m[10]:m[11]]) + `">` +
+				mdInlineHTML(s[m[8]:m[9]]) + "</a>")
+		}
+	}
+	sb.WriteString(html.EscapeString(s[last:]))
+	return sb.String()
+}
+
+// 将html转换为纯文本,md为true时转换为markdown
+func htmlToText(src string, md bool) string {
+	d := xml.NewDecoder(strings.NewReader("<div>" + src + "</div>"))
+	d.Strict, d.AutoClose, d.Entity = false, xml.HTMLAutoClose, xml.HTMLEntity
+
+	var (
+		out   []byte
+		lists []int    // 列表嵌套,-1表示无序列表,>=0表示有序列表当前序号
+		hrefs []string // 链接嵌套
+	)
+	// 换行前去掉行尾空格,n为需要保证的结尾换行数
+	newline := func(n int) {
+		out = bytes.TrimRight(out, " ")
+		if len(out) == 0 {
+			return
+		}
+		for i := len(out) - 1; i >= 0 && out[i] == '\n'; i-- {
+			n--
+		}
+		for ; n > 0; n-- {
+			out = append(out, '\n')
+		}
+	}
+	// 块级元素从新行开始,markdown中块之间需要空行
+	block := func() {
+		if md && len(lists) == 0 {
+			newline(2)
+		} else {
+			newline(1)
+		}
+	}
+	inline := func(mark string) {
+		if md {
+			out = append(out, mark...)
+		}
+	}
+
+	for {
+		tk, err := d.Token()
+		if err != nil {
+			break
+		}
+
+		switch t := tk.(type) {
+		case xml.StartElement:
+			switch strings.ToLower(t.Name.Local) {
+			case "p", "div", "h1", "h2", "h3", "h4", "h5", "h6":
+				block()
+			case "br":
+				out = append(bytes.TrimRight(out, " "), '\n')
+			case "ul":
+				block()
+				lists = append(lists, -1)
+			case "ol":
+				block()
+				lists = append(lists, 0)
+			case "li":
+				newline(1)
+				n := len(lists)
+				if n > 1 {
+					out = append(out, strings.Repeat("  ", n-1)...)
+				}
+				if n > 0 && lists[n-1] >= 0 {
+					lists[n-1]++
+					out = strconv.AppendInt(out, int64(lists[n-1]), 10)
+					out = append(out, ". "...)
+				} else {
+					out = append(out, "- "...)
+				}
+			case "b", "strong":
+				inline("**")
+			case "i", "em":
+				inline("*")
+			case "code":
+				inline("`")
+			case "a":
+				href := ""
+				for _, a := range t.Attr {
+					if strings.EqualFold(a.Name.Local, "href") {
+						href = a.Value
+					}
+				}
+				hrefs = append(hrefs, href)
+				if href != "" {
+					inline("[")
+				}
+			}
+		case xml.EndElement:
+			switch strings.ToLower(t.Name.Local) {
+			case "p", "div", "h1", "h2", "h3", "h4", "h5", "h6":
+				block()
+			case "ul", "ol":
+				if len(lists) > 0 {
+					lists = lists[:len(lists)-1]
+				}
+				block()
+			case "b", "strong":
+				inline("**")
+			case "i", "em":
+				inline("*")
+			case "code":
+				inline("`")
+			case "a":
+				if n := len(hrefs); n > 0 {
+					if hrefs[n-1] != "" {
+						inline("](" + hrefs[n-1] + ")")
+					}
+					hrefs = hrefs[:n-1]
+				}
+			}
+		case xml.CharData:
+			// 连续空白字符合并为一个空格,行首不保留空格
+			text := htmlSpace.ReplaceAll(t, []byte{' '})
+			if len(out) == 0 || out[len(out)-1] == '\n' || out[len(out)-1] == ' ' {
+				text = bytes.TrimLeft(text, " ")
+			}
+			out = append(out, text...)
+		}
+	}
+	return string(bytes.TrimSpace(out))
+}
+
+// 将xmind中notes.html的段落结构转换为html
+//
+//	{"content":{"paragraphs":[{"spans":[{"text":"xx","style":{"fontWeight":"bold"}}]}]}}
+func notesHTML(data json.RawMessage) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	var notes struct {
+		Content struct {
+			Paragraphs []struct {
+				Spans []struct {
+					Text  string            `json:"text"`
+					Href  string            `json:"href"`
+					Style map[string]string `json:"style"`
+				} `json:"spans"`
+			} `json:"paragraphs"`
+		} `json:"content"`
+	}
+	if json.Unmarshal(data, &notes) != nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, p := range notes.Content.Paragraphs {
+		sb.WriteString("<p>")
+		for _, s := range p.Spans {
+			text := strings.ReplaceAll(html.EscapeString(s.Text), "\n", "<br/>")
+			if s.Style["fontWeight"] == "bold" {
+				text = "<strong>" + text + "</strong>"
+			}
+			if s.Style["fontStyle"] == "italic" {
+				text = "<em>" + text + "</em>"
+			}
+			if s.Href != "" {
+				text = `<a href="` + html.EscapeString(s.Href) + `">` + text + "</a>"
+			}
+			sb.WriteString(text)
+		}
+		sb.WriteString("</p>")
+	}
+	return sb.String()
+}
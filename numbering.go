@@ -0,0 +1,178 @@
+package xmind
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type (
+	// Numbering 子主题编号,设置在父主题上,作用于该主题的所有普通子主题
+	Numbering struct {
+		NumberFormat      NumberFormat `json:"numberFormat,omitempty"`
+		Prefix            string       `json:"prefix,omitempty"`            // 编号前缀,例如: 第
+		Suffix            string       `json:"suffix,omitempty"`            // 编号后缀,例如: 章
+		NumberSeparator   string       `json:"numberSeparator,omitempty"`   // 拼接上级编号的分隔符,为空时使用"."
+		PrependingNumbers bool         `json:"prependingNumbers,omitempty"` // 拼接上级编号,例如: 1.2.3
+
+		extra map[string]json.RawMessage // 未定义的字段,保存时原样写回
+	}
+
+	NumberFormat string
+)
+
+//goland:noinspection GoUnusedConst,SpellCheckingInspection
+const (
+	NumberNone        NumberFormat = "org.xmind.numbering.none"            // 不编号
+	NumberArabic      NumberFormat = "org.xmind.numbering.arabic"          // 1,2,3
+	NumberRoman       NumberFormat = "org.xmind.numbering.roman"           // I,II,III
+	NumberLowerRoman  NumberFormat = "org.xmind.numbering.lowercase.roman" // i,ii,iii
+	NumberUpperLetter NumberFormat = "org.xmind.numbering.uppercase"       // A,B,C
+	NumberLowerLetter NumberFormat = "org.xmind.numbering.lowercase"       // a,b,c
+
+	defaultNumberSeparator = "."
+)
+
+var numberingFields = jsonFields(reflect.TypeOf(Numbering{}))
+
+func (n Numbering) MarshalJSON() ([]byte, error) {
+	type numbering Numbering // 避免递归调用
+	data, err := json.Marshal(numbering(n))
+	if err != nil {
+		return nil, err
+	}
+	return marshalExtra(data, n.extra)
+}
+
+func (n *Numbering) UnmarshalJSON(data []byte) error {
+	type numbering Numbering
+	extra, err := unmarshalExtra(data, (*numbering)(n), numberingFields)
+	if err != nil {
+		return err
+	}
+	n.extra = extra
+	return nil
+}
+
+// Format 按照编号格式生成第i个编号,i从1开始,格式不支持时返回空
+func (f NumberFormat) Format(i int) string {
+	if i <= 0 {
+		return ""
+	}
+
+	switch f {
+	case NumberArabic:
+		return strconv.Itoa(i)
+	case NumberRoman:
+		return toRoman(i)
+	case NumberLowerRoman:
+		return strings.ToLower(toRoman(i))
+	case NumberUpperLetter:
+		return toLetters(i)
+	case NumberLowerLetter:
+		return strings.ToLower(toLetters(i))
+	default:
+		return ""
+	}
+}
+
+// 转换为罗马数字,超过3999时使用阿拉伯数字
+func toRoman(i int) string {
+	if i >= 4000 {
+		return strconv.Itoa(i)
+	}
+
+	var sb strings.Builder
+	for _, r := range []struct {
+		value  int
+		symbol string
+	}{
+		{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"}, {100, "C"}, {90, "XC"},
+		{50, "L"}, {40, "XL"}, {10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+	} {
+		for ; i >= r.value; i -= r.value {
+			sb.WriteString(r.symbol)
+		}
+	}
+	return sb.String()
+}
+
+// 转换为字母编号,例如: 1=>A,26=>Z,27=>AA
+func toLetters(i int) string {
+	var buf []byte
+	for ; i > 0; i = (i - 1) / 26 {
+		buf = append([]byte{byte('A' + (i-1)%26)}, buf...)
+	}
+	return string(buf)
+}
+
+// SetNumbering 设置当前主题的子主题编号
+//
+//	param
+//		n: 编号设置,为nil时删除编号
+//		all: 为true时所有子孙主题使用相同的编号设置
+//	return
+//		*Topic: 当前主题地址
+func (st *Topic) SetNumbering(n *Numbering, all ...bool) *Topic {
+	if st == nil {
+		return st
+	}
+
+	set := func(tp *Topic) {
+		if n == nil {
+			tp.Numbering = nil
+		} else {
+			cp := *n // 每个主题使用单独的副本,避免修改时互相影响
+			tp.Numbering = &cp
+		}
+	}
+	set(st)
+	if len(all) > 0 && all[0] {
+		_ = st.Range(func(_ int, tp *Topic) error {
+			set(tp)
+			return nil
+		})
+	}
+	return st
+}
+
+// Number 返回当前主题的编号,例如: 第1.2章,没有编号时返回空
+//
+// 编号由父主题的 Numbering 决定,只有普通子主题才有编号
+func (st *Topic) Number() string {
+	num := st.number()
+	if num == "" {
+		return ""
+	}
+	n := st.parent.Numbering
+	return n.Prefix + num + n.Suffix
+}
+
+// 返回不包含前缀和后缀的编号
+func (st *Topic) number() string {
+	if st == nil || st.parent == nil || st.parent.Numbering == nil ||
+		st.parent.Children == nil {
+		return ""
+	}
+
+	n := st.parent.Numbering
+	for i, tp := range st.parent.Children.Attached {
+		if tp != st {
+			continue
+		}
+
+		num := n.NumberFormat.Format(i + 1)
+		if num != "" && n.PrependingNumbers {
+			if pn := st.parent.number(); pn != "" {
+				sep := n.NumberSeparator
+				if sep == "" {
+					sep = defaultNumberSeparator
+				}
+				num = pn + sep + num
+			}
+		}
+		return num
+	}
+	return "" // 自由主题,概要主题等没有编号
+}
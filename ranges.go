@@ -0,0 +1,182 @@
+package xmind
+
+import (
+	"fmt"
+)
+
+// 生成xmind的范围字符串,例如: (0,2)
+func fmtRange(from, to int) string {
+	return fmt.Sprintf("(%d,%d)", from, to)
+}
+
+// 解析xmind的范围字符串,解析失败时ok为false
+func parseRange(s string) (from, to int, ok bool) {
+	_, err := fmt.Sscanf(s, "(%d,%d)", &from, &to)
+	return from, to, err == nil && from >= 0 && from <= to
+}
+
+// 检查范围是否在当前主题的普通子主题之内
+func (st *Topic) inRange(from, to int) bool {
+	return st.Children != nil && from >= 0 && from <= to && to < len(st.Children.Attached)
+}
+
+// AddSummary 为当前主题的子主题添加概要
+//
+//	param
+//		fromIdx: 概要包含的第一个子主题下标,从0开始
+//		toIdx: 概要包含的最后一个子主题下标
+//		title: 概要主题内容
+//	return
+//		*Topic: 当前主题地址
+//
+// 下标超出范围时不做任何操作,概要主题会在 Range 中被遍历到,可以用 On 切换过去继续操作
+func (st *Topic) AddSummary(fromIdx, toIdx int, title string) *Topic {
+	if st == nil || st.parent == nil || !st.inRange(fromIdx, toIdx) {
+		return st
+	}
+
+	tp := st.newTopic(title)
+	st.Children.Summary = append(st.Children.Summary, tp)
+	st.Summaries = append(st.Summaries, &Summary{
+		ID:      GetId(),
+		Range:   fmtRange(fromIdx, toIdx),
+		TopicID: tp.ID,
+	})
+	return st
+}
+
+// SummaryTopic 返回概要对应的概要主题,找不到时返回nil
+func (st *Topic) SummaryTopic(sm *Summary) *Topic {
+	if st == nil || st.Children == nil || sm == nil {
+		return nil
+	}
+	for _, tp := range st.Children.Summary {
+		if tp.ID == sm.TopicID {
+			return tp
+		}
+	}
+	return nil
+}
+
+// AddBoundary 为当前主题的子主题添加外框
+//
+//	param
+//		fromIdx: 外框包含的第一个子主题下标,从0开始,小于0时外框包含整个主题
+//		toIdx: 外框包含的最后一个子主题下标
+//		title: 外框标题,可以为空
+//		style: 外框样式,不传则使用默认样式
+//	return
+//		*Topic: 当前主题地址
+func (st *Topic) AddBoundary(fromIdx, toIdx int, title string, style ...*Style) *Topic {
+	if st == nil {
+		return st
+	}
+
+	b := &Boundary{ID: GetId(), Range: BoundaryMaster, Title: title}
+	if fromIdx >= 0 {
+		if !st.inRange(fromIdx, toIdx) {
+			return st // 下标超出范围时不做任何操作
+		}
+		b.Range = fmtRange(fromIdx, toIdx)
+	}
+	if len(style) > 0 {
+		b.Style = style[0]
+	}
+	st.Boundaries = append(st.Boundaries, b)
+	return st
+}
+
+// 记录当前主题概要和外框包含的子主题,返回的方法在子主题顺序变化后重新计算范围
+// 包含的子主题全部被移走时,会同时删除该概要以及概要主题,或者删除该外框
+// moved为被移动的子主题,计算范围时不再包含它,除非范围内只有被移动的子主题
+func (st *Topic) holdRanges(moved ...*Topic) func() {
+	if st == nil || st.Children == nil ||
+		(len(st.Summaries) == 0 && len(st.Boundaries) == 0) {
+		return func() {}
+	}
+
+	hold := make(map[*string][]*Topic, len(st.Summaries)+len(st.Boundaries))
+	add := func(r *string) {
+		from, to, ok := parseRange(*r)
+		if ok && to >= len(st.Children.Attached) {
+			to = len(st.Children.Attached) - 1
+		}
+		if ok && from <= to {
+			hold[r] = append([]*Topic(nil), st.Children.Attached[from:to+1]...)
+		}
+	}
+	for _, sm := range st.Summaries {
+		add(&sm.Range)
+	}
+	for _, b := range st.Boundaries {
+		add(&b.Range)
+	}
+
+	return func() {
+		index := make(map[*Topic]int)
+		if st.Children != nil {
+			for i, tp := range st.Children.Attached {
+				index[tp] = i
+			}
+		}
+
+		// 重新计算范围,返回false表示包含的子主题已全部移走
+		fix := func(r *string) bool {
+			tps, ok := hold[r]
+			if !ok {
+				return true // 新增或无法解析的范围不做处理
+			}
+
+			from, to := -1, -1
+			for _, skip := range []bool{true, false} {
+				for _, tp := range tps {
+					if skip && len(moved) > 0 && tp == moved[0] {
+						continue // 移出范围的子主题不再包含在范围内
+					}
+					if i, ok := index[tp]; ok {
+						if from < 0 || i < from {
+							from = i
+						}
+						if i > to {
+							to = i
+						}
+					}
+				}
+				if from >= 0 {
+					break
+				}
+			}
+			if from < 0 {
+				return false
+			}
+			*r = fmtRange(from, to)
+			return true
+		}
+
+		var drop []TopicID
+		for _, sm := range st.Summaries {
+			if !fix(&sm.Range) {
+				drop = append(drop, sm.TopicID)
+			}
+		}
+		for _, id := range drop {
+			if tp := st.removeChild(id); tp != nil {
+				delete(st.resources, tp.ID)
+				tp.RemoveChildren() // 同时删除指向概要主题的联系
+			}
+		}
+
+		cur := 0
+		for i, b := range st.Boundaries {
+			if fix(&b.Range) {
+				st.Boundaries[cur] = st.Boundaries[i]
+				cur++
+			}
+		}
+		if cur == 0 {
+			st.Boundaries = nil
+		} else {
+			st.Boundaries = st.Boundaries[:cur]
+		}
+	}
+}
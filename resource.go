@@ -0,0 +1,186 @@
+package xmind
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const xapPrefix = "xap:" // xmind压缩包内部文件的链接前缀
+
+// 根据文件内容生成压缩包内的路径,相同内容的文件只会保存一份
+//
+//	例如: resources/0a1b2c...(32个字符).png
+func resourcePath(name string, data []byte) string {
+	sum := sha256.Sum256(data)
+	return Resources + "/" + hex.EncodeToString(sum[:16]) + strings.ToLower(path.Ext(name))
+}
+
+// AddImage 在当前主题上添加图片
+//
+//	param
+//		name: 图片名称,只用于获取图片后缀,例如: chart.png
+//		r: 图片数据
+//		width,height: 图片显示的宽高,为0时使用xmind默认值
+//	return
+//		error: 读取图片数据失败时返回错误
+func (st *Topic) AddImage(name string, r io.Reader, width, height int) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	st.Image = &Image{
+		Src:    xapPrefix + resourcePath(name, data),
+		Width:  width,
+		Height: height,
+		data:   data,
+	}
+	return nil
+}
+
+// Path 返回图片在压缩包中的路径,不是压缩包内部图片时返回空
+func (img *Image) Path() string {
+	if img == nil || !strings.HasPrefix(img.Src, xapPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(img.Src, xapPrefix)
+}
+
+// Data 返回图片数据,添加的图片或者通过 LoadFrom 加载的图片才有数据
+func (img *Image) Data() []byte {
+	if img == nil {
+		return nil
+	}
+	return img.data
+}
+
+// AddAttachment 在当前主题上添加附件,附件会保存到xmind压缩包中
+//
+//	param
+//		name: 附件名称,用于获取附件后缀,导出附件时也会参考该名称
+//		r: 附件数据
+//	return
+//		error: 读取附件数据失败时返回错误
+//
+// 附件使用主题超链接保存,会覆盖当前主题的超链接
+func (st *Topic) AddAttachment(name string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	st.Href, st.attach = xapPrefix+resourcePath(name, data), data
+	return nil
+}
+
+// AttachmentData 返回当前主题的附件数据,没有附件时返回nil
+func (st *Topic) AttachmentData() []byte {
+	if st == nil || st.attachPath() == "" {
+		return nil
+	}
+	return st.attach
+}
+
+// 返回附件在压缩包中的路径,不是附件时返回空
+func (st *Topic) attachPath() string {
+	if !strings.HasPrefix(st.Href, xapPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(st.Href, xapPrefix)
+}
+
+// Attachment 附件信息
+type Attachment struct {
+	Topic *Topic // 附件所在主题
+	Path  string // 附件在压缩包中的路径
+	Name  string // 附件文件名
+	Data  []byte // 附件数据
+}
+
+// Attachments 返回所有画布中的附件
+func (wk *WorkBook) Attachments() (res []Attachment) {
+	if wk == nil {
+		return
+	}
+
+	for _, sheet := range wk.Topics {
+		_ = sheet.Range(func(_ int, tp *Topic) error {
+			if data := tp.AttachmentData(); data != nil {
+				at := Attachment{Topic: tp, Path: tp.attachPath(), Data: data}
+				// xmind添加附件时会用文件名作为主题内容,后缀一致时优先使用主题内容作为文件名
+				at.Name = filepath.Base(tp.Title)
+				if path.Ext(at.Name) != path.Ext(at.Path) || at.Name == "." || at.Name == "/" {
+					at.Name = path.Base(at.Path)
+				}
+				res = append(res, at)
+			}
+			return nil
+		})
+	}
+	return
+}
+
+// ExtractAttachments 将所有附件保存到指定目录,同名附件后保存的会覆盖先保存的
+func (wk *WorkBook) ExtractAttachments(dir string) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	for _, at := range wk.Attachments() {
+		err = os.WriteFile(filepath.Join(dir, at.Name), at.Data, 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 从压缩包中读取所有主题引用的文件数据,压缩包中不存在的文件直接忽略
+func (wk *WorkBook) loadResources(ar *archiveReader) {
+	read := func(name string) []byte {
+		data, _ := ar.read(name)
+		return data
+	}
+
+	for _, sheet := range wk.Topics {
+		_ = sheet.Range(func(_ int, tp *Topic) error {
+			if name := tp.Image.Path(); name != "" {
+				tp.Image.data = read(name)
+			}
+			if name := tp.attachPath(); name != "" {
+				tp.attach = read(name)
+			}
+			return nil
+		})
+	}
+}
+
+// 将所有主题引用的文件数据写入压缩包,相同路径的文件只写入一次
+func (wk *WorkBook) saveResources(aw *archiveWriter) error {
+	write := func(name string, data []byte) error {
+		if name == "" || data == nil || aw.has(name) {
+			return nil
+		}
+		return aw.write(name, data)
+	}
+
+	for _, sheet := range wk.Topics {
+		err := sheet.Range(func(_ int, tp *Topic) error {
+			err := write(tp.Image.Path(), tp.Image.Data())
+			if err != nil {
+				return err
+			}
+			return write(tp.attachPath(), tp.AttachmentData())
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
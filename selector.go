@@ -0,0 +1,340 @@
+package xmind
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 路径中每一级主题内容的分隔符,主题内容中的特殊字符使用 \ 转义
+const pathSeparator = '/'
+
+// 选择器中的一级路径
+type pathSegment struct {
+	title   string
+	any     bool                     // * 匹配任意一个主题
+	deep    bool                     // ** 匹配零到多级主题
+	filters []func(int, *Topic) bool // 过滤条件,全部满足才匹配
+}
+
+func (ps *pathSegment) match(depth int, tp *Topic) bool {
+	if !ps.any && tp.Title != ps.title {
+		return false
+	}
+	for _, f := range ps.filters {
+		if !f(depth, tp) {
+			return false
+		}
+	}
+	return true
+}
+
+// Path 返回当前主题的路径,由中心主题到当前主题的内容组成,例如: /中心主题/Backend/API
+//
+// 主题内容中的 \ / [ * 会使用 \ 转义,返回值可以直接用于 OnPath 和 Select
+// 主题内容为空时对应一级空路径,例如: /中心主题//API
+func (st *Topic) Path() string {
+	if st == nil || st.parent == nil {
+		return "" // 根节点没有路径
+	}
+
+	var titles []string
+	for tp := st; tp.parent != nil; tp = tp.parent {
+		titles = append(titles, escapePath(tp.Title))
+	}
+	var sb strings.Builder
+	for i := len(titles) - 1; i >= 0; i-- {
+		sb.WriteByte(pathSeparator)
+		sb.WriteString(titles[i])
+	}
+	return sb.String()
+}
+
+// 返回当前主题的层级,中心主题为1,根节点为0
+func (st *Topic) depth() (depth int) {
+	for tp := st; tp != nil && tp.parent != nil; tp = tp.parent {
+		depth++
+	}
+	return
+}
+
+// OnPath 根据路径切换主题地址,找不到时切换到最后编辑的主题,同 On
+//
+//	param
+//		path: 主题路径,使用 / 分隔每一级主题内容,例如: Backend/API/Auth
+//			以 / 开头时从中心主题开始匹配,例如 Path 的返回值,否则从当前主题的子主题开始匹配
+//			主题内容中的 / 使用 \/ 转义,\ 使用 \\ 转义,空的一级路径匹配内容为空的主题
+//	return
+//		*Topic: 匹配主题地址,有多个匹配时返回画布中的第一个
+func (st *Topic) OnPath(path string) *Topic {
+	tp, err := st.LookupPath(path)
+	if err != nil {
+		if st == nil || st.resources == nil {
+			return st
+		}
+		return st.resources[lastKey]
+	}
+	return tp
+}
+
+// LookupPath 同 OnPath 根据路径切换主题地址,找不到时返回 ErrNotFound
+func (st *Topic) LookupPath(path string) (*Topic, error) {
+	abs, segs, _ := parsePath(path, true)
+	res := st.selectPath(abs, segs)
+	if len(res) == 0 {
+		return nil, ErrNotFound
+	}
+	if st.resources != nil {
+		st.resources[lastKey] = res[0]
+	}
+	return res[0], nil
+}
+
+// Select 根据选择器查找所有匹配的主题
+//
+//	param
+//		selector: 选择器,路径格式同 OnPath,每一级还支持通配符和过滤条件,例如: /**/API/*[label=v1]
+//			*: 匹配任意一个主题
+//			**: 匹配零到多级主题,在结尾时匹配所有子孙主题,带过滤条件时等价于 **/*[...]
+//			[label=x]: 有标签x,[label]: 有任意标签
+//			[depth<=N]: 主题层级,中心主题为1,支持 <,<=,=,>=,>
+//			多个过滤条件需要全部满足,例如: *[label][depth<3],没有主题内容时等价于 *
+//	return
+//		[]*Topic: 匹配的所有主题,按照主题在画布中的顺序排列,选择器格式错误时返回nil
+//
+// 需要区分选择器格式错误和没有匹配主题时,使用 TrySelect
+func (st *Topic) Select(selector string) []*Topic {
+	res, _ := st.TrySelect(selector)
+	return res
+}
+
+// TrySelect 同 Select 根据选择器查找所有匹配的主题,选择器格式错误时返回错误
+func (st *Topic) TrySelect(selector string) ([]*Topic, error) {
+	abs, segs, err := parsePath(selector, false)
+	if err != nil {
+		return nil, err
+	}
+	return st.selectPath(abs, segs), nil
+}
+
+func (st *Topic) selectPath(abs bool, segs []pathSegment) []*Topic {
+	if st == nil || len(segs) == 0 {
+		return nil
+	}
+
+	top := st
+	for top.parent != nil {
+		top = top.parent // 找到画布根节点,用于绝对路径和结果排序
+	}
+	cent := top
+	if top.RootTopic != nil {
+		cent = top.RootTopic
+	}
+
+	var (
+		cands []*Topic
+		depth int
+	)
+	if abs || st == top {
+		cands, depth = []*Topic{cent}, 1 // 根节点的相对路径同样从中心主题开始匹配
+	} else {
+		cands, depth = st.Children.topics(), st.depth()+1
+	}
+
+	matched := make(map[*Topic]bool)
+	matchPath(cands, depth, segs, matched)
+	if len(matched) == 0 {
+		return nil
+	}
+
+	res := make([]*Topic, 0, len(matched))
+	_ = top.Range(func(_ int, tp *Topic) error {
+		if matched[tp] {
+			res = append(res, tp)
+		}
+		return nil
+	})
+	return res
+}
+
+// 在cands及其子孙主题中匹配segs,depth为cands所在层级,匹配的主题记录到res
+func matchPath(cands []*Topic, depth int, segs []pathSegment, res map[*Topic]bool) {
+	seg, rest := segs[0], segs[1:]
+	if seg.deep {
+		matchPath(cands, depth, rest, res) // ** 匹配零级,解析时保证 ** 不在结尾
+		for _, tp := range cands {
+			if children := tp.Children.topics(); len(children) > 0 {
+				matchPath(children, depth+1, segs, res) // ** 匹配一级后继续匹配
+			}
+		}
+		return
+	}
+
+	for _, tp := range cands {
+		if !seg.match(depth, tp) {
+			continue
+		}
+		if len(rest) == 0 {
+			res[tp] = true
+		} else if children := tp.Children.topics(); len(children) > 0 {
+			matchPath(children, depth+1, rest, res)
+		}
+	}
+}
+
+// 解析路径,literal为true时不支持通配符和过滤条件,用于 OnPath
+func parsePath(path string, literal bool) (abs bool, segs []pathSegment, err error) {
+	if len(path) > 0 && path[0] == pathSeparator {
+		abs, path = true, path[1:]
+	} else if path == "" {
+		return // 空的相对路径不匹配任何主题
+	}
+
+	for _, raw := range splitPath(path) {
+		if literal {
+			segs = append(segs, pathSegment{title: unescapePath(raw)})
+			continue
+		}
+
+		seg, err := parseSegment(raw)
+		if err != nil {
+			return false, nil, err
+		}
+		if seg.deep && len(seg.filters) > 0 {
+			// **[...] 等价于 **/*[...]
+			segs = append(segs, pathSegment{deep: true}, pathSegment{any: true, filters: seg.filters})
+		} else {
+			segs = append(segs, seg)
+		}
+	}
+	if n := len(segs); n > 0 && segs[n-1].deep {
+		segs = append(segs, pathSegment{any: true}) // 结尾的 ** 匹配所有子孙主题,等价于 **/*
+	}
+	return
+}
+
+// 解析一级路径,格式为: 主题内容[过滤条件][过滤条件]
+func parseSegment(raw string) (seg pathSegment, err error) {
+	name, rest := raw, ""
+	if i := indexUnescaped(raw, '['); i >= 0 {
+		name, rest = raw[:i], raw[i:]
+	}
+
+	switch {
+	case name == "*" || name == "" && rest != "":
+		seg.any = true // 只有过滤条件时匹配任意主题
+	case name == "**":
+		seg.deep = true
+	default:
+		seg.title = unescapePath(name) // 为空时匹配内容为空的主题
+	}
+
+	for rest != "" {
+		i := indexUnescaped(rest, ']')
+		if rest[0] != '[' || i < 0 {
+			return seg, fmt.Errorf("invalid selector: %s", raw)
+		}
+		f, err := parseFilter(unescapePath(rest[1:i]))
+		if err != nil {
+			return seg, err
+		}
+		seg.filters = append(seg.filters, f)
+		rest = rest[i+1:]
+	}
+	return
+}
+
+// 解析过滤条件,支持: label,label=x,depth<=N
+func parseFilter(expr string) (func(int, *Topic) bool, error) {
+	if expr == "label" {
+		return func(_ int, tp *Topic) bool { return len(tp.Labels) > 0 }, nil
+	}
+	if strings.HasPrefix(expr, "label=") {
+		label := expr[len("label="):]
+		return func(_ int, tp *Topic) bool { return contains(tp.Labels, label) }, nil
+	}
+
+	if strings.HasPrefix(expr, "depth") {
+		cmp := strings.TrimSpace(expr[len("depth"):])
+		for _, op := range []string{"<=", ">=", "<", ">", "="} {
+			if !strings.HasPrefix(cmp, op) {
+				continue
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(cmp[len(op):]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid depth filter: %s", expr)
+			}
+			return func(depth int, _ *Topic) bool {
+				switch op {
+				case "<=":
+					return depth <= n
+				case ">=":
+					return depth >= n
+				case "<":
+					return depth < n
+				case ">":
+					return depth > n
+				default:
+					return depth == n
+				}
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported filter: %s", expr)
+}
+
+// 按未转义的 / 拆分路径,返回的每一级保留转义字符
+func splitPath(path string) (res []string) {
+	start := 0
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			i++ // 跳过被转义的字符
+		case pathSeparator:
+			res = append(res, path[start:i])
+			start = i + 1
+		}
+	}
+	return append(res, path[start:])
+}
+
+// 返回第一个未转义的c所在位置,找不到时返回-1
+func indexUnescaped(s string, c byte) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+		} else if s[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+func escapePath(s string) string {
+	if !strings.ContainsAny(s, `\/[*`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\', pathSeparator, '[', '*':
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+func unescapePath(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++ // 保留被转义的字符
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
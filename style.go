@@ -0,0 +1,213 @@
+package xmind
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+)
+
+type (
+	// StyleProperties 常用样式属性,其他属性可以通过 Get,Set 方法读写
+	StyleProperties struct {
+		Fill        string     `json:"svg:fill,omitempty"`          // 填充颜色,例如: #ff0000
+		FontFamily  string     `json:"fo:font-family,omitempty"`    // 字体
+		FontSize    string     `json:"fo:font-size,omitempty"`      // 字号,例如: 14pt
+		FontWeight  string     `json:"fo:font-weight,omitempty"`    // 字重,例如: bold
+		FontStyle   string     `json:"fo:font-style,omitempty"`     // 字体样式,例如: italic
+		Color       string     `json:"fo:color,omitempty"`          // 文字颜色
+		BorderColor string     `json:"border-line-color,omitempty"` // 边框颜色
+		BorderWidth string     `json:"border-line-width,omitempty"` // 边框宽度,例如: 2pt
+		LineClass   LineClass  `json:"line-class,omitempty"`        // 连线形状
+		LineColor   string     `json:"line-color,omitempty"`        // 连线颜色
+		LineWidth   string     `json:"line-width,omitempty"`        // 连线宽度
+		ShapeClass  ShapeClass `json:"shape-class,omitempty"`       // 主题形状
+
+		extra map[string]json.RawMessage // 未定义的属性,保存时原样写回
+	}
+
+	ShapeClass string
+	LineClass  string
+)
+
+//goland:noinspection GoUnusedConst,SpellCheckingInspection
+const (
+	ShapeRoundedRect   ShapeClass = "org.xmind.topicShape.roundedRect"   // 圆角矩形
+	ShapeRect          ShapeClass = "org.xmind.topicShape.rect"          // 矩形
+	ShapeEllipse       ShapeClass = "org.xmind.topicShape.ellipserect"   // 椭圆
+	ShapeCircle        ShapeClass = "org.xmind.topicShape.circle"        // 圆形
+	ShapeDiamond       ShapeClass = "org.xmind.topicShape.diamond"       // 菱形
+	ShapeParallelogram ShapeClass = "org.xmind.topicShape.parallelogram" // 平行四边形
+	ShapeUnderline     ShapeClass = "org.xmind.topicShape.underline"     // 下划线
+	ShapeNone          ShapeClass = "org.xmind.topicShape.none"          // 无边框
+
+	LineCurve        LineClass = "org.xmind.branchConnection.curve"        // 曲线
+	LineStraight     LineClass = "org.xmind.branchConnection.straight"     // 直线
+	LineElbow        LineClass = "org.xmind.branchConnection.elbow"        // 折线
+	LineRoundedElbow LineClass = "org.xmind.branchConnection.roundedElbow" // 圆角折线
+	LineBight        LineClass = "org.xmind.branchConnection.bight"        // 弯曲线
+	LineNone         LineClass = "org.xmind.branchConnection.none"         // 无连线
+
+	styleTypeTopic = "topic"
+)
+
+var stylePropertiesFields = jsonFields(reflect.TypeOf(StyleProperties{}))
+
+func (sp StyleProperties) MarshalJSON() ([]byte, error) {
+	type properties StyleProperties // 避免递归调用
+	data, err := json.Marshal(properties(sp))
+	if err != nil {
+		return nil, err
+	}
+	return marshalExtra(data, sp.extra)
+}
+
+func (sp *StyleProperties) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	type properties StyleProperties
+	extra, err := unmarshalExtra(data, (*properties)(sp), stylePropertiesFields)
+	if err != nil {
+		return err
+	}
+	sp.extra = extra
+	return nil
+}
+
+// Get 获取未定义的样式属性,属性不存在或不是字符串时返回空
+func (sp *StyleProperties) Get(key string) string {
+	var value string
+	if raw, ok := sp.extra[key]; ok {
+		_ = json.Unmarshal(raw, &value)
+	}
+	return value
+}
+
+// Set 设置未定义的样式属性,value为空时删除该属性
+func (sp *StyleProperties) Set(key, value string) {
+	if value == "" {
+		delete(sp.extra, key)
+		return
+	}
+	if sp.extra == nil {
+		sp.extra = make(map[string]json.RawMessage)
+	}
+	sp.extra[key] = json.RawMessage(strconv.Quote(value))
+}
+
+// IsZero 判断样式没有任何内容
+func (s *Style) IsZero() bool {
+	p := s.Properties
+	if len(p.extra) > 0 || len(s.extra) > 0 {
+		return false
+	}
+	p.extra = nil
+	return s.Id == "" && s.Type == "" && reflect.ValueOf(p).IsZero()
+}
+
+// 返回当前主题的样式属性,并确保样式ID和类型有值
+func (st *Topic) styleProperties() *StyleProperties {
+	if st.Style.Id == "" {
+		st.Style.Id = GetId()
+	}
+	if st.Style.Type == "" {
+		st.Style.Type = styleTypeTopic
+	}
+	return &st.Style.Properties
+}
+
+// SetFill 设置主题填充颜色
+//
+//	param
+//		color: 颜色,例如: #ff0000
+//	return
+//		*Topic: 当前主题地址
+func (st *Topic) SetFill(color string) *Topic {
+	st.styleProperties().Fill = color
+	return st
+}
+
+// SetFont 设置主题字体
+//
+//	param
+//		family: 字体名称,为空时不修改
+//		size: 字号,单位pt,为0时不修改
+//		color: 文字颜色,为空时不修改
+//	return
+//		*Topic: 当前主题地址
+func (st *Topic) SetFont(family string, size int, color string) *Topic {
+	sp := st.styleProperties()
+	if family != "" {
+		sp.FontFamily = family
+	}
+	if size > 0 {
+		sp.FontSize = strconv.Itoa(size) + "pt"
+	}
+	if color != "" {
+		sp.Color = color
+	}
+	return st
+}
+
+// SetBold 设置主题文字是否加粗
+func (st *Topic) SetBold(bold bool) *Topic {
+	if bold {
+		st.styleProperties().FontWeight = "bold"
+	} else {
+		st.styleProperties().FontWeight = "normal"
+	}
+	return st
+}
+
+// SetItalic 设置主题文字是否斜体
+func (st *Topic) SetItalic(italic bool) *Topic {
+	if italic {
+		st.styleProperties().FontStyle = "italic"
+	} else {
+		st.styleProperties().FontStyle = "normal"
+	}
+	return st
+}
+
+// SetBorder 设置主题边框
+//
+//	param
+//		color: 边框颜色,为空时不修改
+//		width: 边框宽度,单位pt,为0时不修改
+//	return
+//		*Topic: 当前主题地址
+func (st *Topic) SetBorder(color string, width int) *Topic {
+	sp := st.styleProperties()
+	if color != "" {
+		sp.BorderColor = color
+	}
+	if width > 0 {
+		sp.BorderWidth = strconv.Itoa(width) + "pt"
+	}
+	return st
+}
+
+// SetLine 设置主题到子主题的连线
+//
+//	param
+//		class: 连线形状,为空时不修改
+//		color: 连线颜色,为空时不修改
+//	return
+//		*Topic: 当前主题地址
+func (st *Topic) SetLine(class LineClass, color string) *Topic {
+	sp := st.styleProperties()
+	if class != "" {
+		sp.LineClass = class
+	}
+	if color != "" {
+		sp.LineColor = color
+	}
+	return st
+}
+
+// SetShape 设置主题形状
+func (st *Topic) SetShape(class ShapeClass) *Topic {
+	st.styleProperties().ShapeClass = class
+	return st
+}
@@ -0,0 +1,303 @@
+package xmind
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+)
+
+// ThumbnailPath 缩略图在压缩包中的路径,文件管理器和xmind欢迎页会显示该图片
+const ThumbnailPath = Thumbnails + "/thumbnail.png"
+
+const (
+	thumbMaxWidth  = 800 // 缩略图最大宽度
+	thumbMaxHeight = 600 // 缩略图最大高度
+	thumbMargin    = 20  // 缩略图边距
+	thumbGapMain   = 40  // 主题和子主题之间的距离
+	thumbGapCross  = 12  // 兄弟主题之间的距离
+	thumbCharWidth = 7   // 每个字符的宽度,中文等宽字符按2个字符计算
+)
+
+// 缩略图中主题布局方向
+type thumbDir uint8
+
+const (
+	thumbRight thumbDir = iota
+	thumbLeft
+	thumbDown
+	thumbUp
+)
+
+func (d thumbDir) vertical() bool { return d == thumbDown || d == thumbUp }
+
+// 缩略图中的主题框
+type thumbNode struct {
+	w, h     float64 // 主题框大小
+	x, y     float64 // 主题框左上角坐标
+	span     float64 // 子树在布局交叉方向上占用的大小
+	deep     int
+	text     float64 // 主题内容长度,用于绘制文字占位条
+	dir      thumbDir
+	children []*thumbNode
+}
+
+// 根据主题生成缩略图节点树,收缩的主题不显示子主题
+func newThumbNode(tp *Topic, deep int) *thumbNode {
+	text := 0.0
+	for _, r := range tp.Title {
+		if r >= 0x1100 {
+			text += 2 // 中文等宽字符
+		} else {
+			text++
+		}
+	}
+
+	n := &thumbNode{deep: deep, text: text * thumbCharWidth, h: 28}
+	switch deep {
+	case 1:
+		n.h = 44
+	case 2:
+		n.h = 34
+	}
+	n.w = math.Max(math.Min(n.text, 240)+24, 48)
+
+	if tp.Branch != folded && tp.Children != nil {
+		for _, tc := range tp.Children.Attached {
+			n.children = append(n.children, newThumbNode(tc, deep+1))
+		}
+	}
+	return n
+}
+
+// 计算子树在交叉方向上占用的大小
+func (n *thumbNode) measure(dir thumbDir) float64 {
+	n.dir = dir
+	size := n.h
+	if dir.vertical() {
+		size = n.w
+	}
+
+	sum := 0.0
+	for i, c := range n.children {
+		if i > 0 {
+			sum += thumbGapCross
+		}
+		sum += c.measure(dir)
+	}
+	n.span = math.Max(size, sum)
+	return n.span
+}
+
+// 放置子树,main为主方向上的起始位置,cross为交叉方向上的起始位置
+func (n *thumbNode) place(main, cross float64) {
+	switch n.dir {
+	case thumbRight:
+		n.x, n.y = main, cross+(n.span-n.h)/2
+	case thumbLeft:
+		n.x, n.y = main-n.w, cross+(n.span-n.h)/2
+	case thumbDown:
+		n.x, n.y = cross+(n.span-n.w)/2, main
+	case thumbUp:
+		n.x, n.y = cross+(n.span-n.w)/2, main-n.h
+	}
+	n.placeChildren(n.children)
+}
+
+// 放置一组子主题,子主题整体在交叉方向上以当前主题为中心
+func (n *thumbNode) placeChildren(children []*thumbNode) {
+	if len(children) == 0 {
+		return
+	}
+
+	dir, total := children[0].dir, 0.0
+	for i, c := range children {
+		if i > 0 {
+			total += thumbGapCross
+		}
+		total += c.span
+	}
+
+	var main, cross float64
+	switch dir {
+	case thumbRight:
+		main, cross = n.x+n.w+thumbGapMain, n.y+n.h/2-total/2
+	case thumbLeft:
+		main, cross = n.x-thumbGapMain, n.y+n.h/2-total/2
+	case thumbDown:
+		main, cross = n.y+n.h+thumbGapMain, n.x+n.w/2-total/2
+	case thumbUp:
+		main, cross = n.y-thumbGapMain, n.x+n.w/2-total/2
+	}
+	for _, c := range children {
+		c.place(main, cross)
+		cross += c.span + thumbGapCross
+	}
+}
+
+// 遍历所有节点
+func (n *thumbNode) walk(f func(parent, node *thumbNode)) {
+	for _, c := range n.children {
+		f(n, c)
+		c.walk(f)
+	}
+}
+
+// 根据结构返回子主题的布局方向,split为true表示子主题分布在左右两侧
+func thumbLayout(sc StructureClass) (dir thumbDir, split bool) {
+	switch sc {
+	case StructMap, StructMapUnbalanced, StructMapClockwise, StructMapAnticlockwise:
+		return thumbRight, true
+	case StructLogicLeft, StructTreeLeft, StructFishHoneRightHeaded:
+		return thumbLeft, false
+	case StructOrgChartDown, StructTimelineVertical, StructSpreadsheet, StructSpreadsheetColumn:
+		return thumbDown, false
+	case StructOrgChartUp:
+		return thumbUp, false
+	default:
+		return thumbRight, false
+	}
+}
+
+// 根据第一个不为nil的画布生成缩略图,只绘制主题框和连线,布局按照中心主题的结构计算
+// 画布没有中心主题时返回nil
+func renderThumbnail(sheets []*Topic) ([]byte, error) {
+	var cent *Topic
+	for _, sheet := range sheets {
+		if sheet = sheet.On(rootKey); sheet != nil {
+			cent = sheet.RootTopic // 跳过为nil的画布
+			break
+		}
+	}
+	if cent == nil {
+		return nil, nil
+	}
+
+	root := newThumbNode(cent, 1)
+	root.x, root.y = -root.w/2, -root.h/2
+
+	dir, split := thumbLayout(cent.StructureClass)
+	if split {
+		// 平衡图前一半子主题在右侧,后一半在左侧
+		half := (len(root.children) + 1) / 2
+		for _, c := range root.children[:half] {
+			c.measure(thumbRight)
+		}
+		for _, c := range root.children[half:] {
+			c.measure(thumbLeft)
+		}
+		root.placeChildren(root.children[:half])
+		root.placeChildren(root.children[half:])
+	} else {
+		for _, c := range root.children {
+			c.measure(dir)
+		}
+		root.placeChildren(root.children)
+	}
+
+	// 计算边界,并缩放到缩略图大小
+	minX, minY, maxX, maxY := root.x, root.y, root.x+root.w, root.y+root.h
+	root.walk(func(_, n *thumbNode) {
+		minX, minY = math.Min(minX, n.x), math.Min(minY, n.y)
+		maxX, maxY = math.Max(maxX, n.x+n.w), math.Max(maxY, n.y+n.h)
+	})
+	scale := math.Min(1, math.Min(
+		(thumbMaxWidth-2*thumbMargin)/(maxX-minX),
+		(thumbMaxHeight-2*thumbMargin)/(maxY-minY)))
+	pos := func(x, y float64) (int, int) {
+		return int(math.Round((x-minX)*scale)) + thumbMargin,
+			int(math.Round((y-minY)*scale)) + thumbMargin
+	}
+
+	w, h := pos(maxX, maxY)
+	img := image.NewRGBA(image.Rect(0, 0, w+thumbMargin, h+thumbMargin))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}),
+		image.Point{}, draw.Src)
+
+	lineColor := color.RGBA{R: 0x8c, G: 0x96, B: 0xa3, A: 0xff}
+	root.walk(func(p, n *thumbNode) {
+		var x0, y0, x1, y1 float64
+		switch n.dir {
+		case thumbRight:
+			x0, y0, x1, y1 = p.x+p.w, p.y+p.h/2, n.x, n.y+n.h/2
+		case thumbLeft:
+			x0, y0, x1, y1 = p.x, p.y+p.h/2, n.x+n.w, n.y+n.h/2
+		case thumbDown:
+			x0, y0, x1, y1 = p.x+p.w/2, p.y+p.h, n.x+n.w/2, n.y
+		case thumbUp:
+			x0, y0, x1, y1 = p.x+p.w/2, p.y, n.x+n.w/2, n.y+n.h
+		}
+		ax, ay := pos(x0, y0)
+		bx, by := pos(x1, y1)
+		drawLine(img, ax, ay, bx, by, lineColor)
+	})
+
+	box := func(n *thumbNode) {
+		fill, border, text := color.RGBA{R: 0xe8, G: 0xf0, B: 0xfa, A: 0xff},
+			color.RGBA{R: 0x4a, G: 0x90, B: 0xd9, A: 0xff}, color.RGBA{R: 0x9d, G: 0xb4, B: 0xcf, A: 0xff}
+		switch n.deep {
+		case 1:
+			fill, border, text = color.RGBA{R: 0x2c, G: 0x3e, B: 0x50, A: 0xff},
+				color.RGBA{R: 0x1a, G: 0x25, B: 0x2f, A: 0xff}, color.RGBA{R: 0xd5, G: 0xdb, B: 0xe1, A: 0xff}
+		case 2:
+			fill, border, text = color.RGBA{R: 0x4a, G: 0x90, B: 0xd9, A: 0xff},
+				color.RGBA{R: 0x35, G: 0x6f, B: 0xad, A: 0xff}, color.RGBA{R: 0xd6, G: 0xe6, B: 0xf7, A: 0xff}
+		}
+
+		x0, y0 := pos(n.x, n.y)
+		x1, y1 := pos(n.x+n.w, n.y+n.h)
+		r := image.Rect(x0, y0, x1, y1)
+		draw.Draw(img, r, image.NewUniform(border), image.Point{}, draw.Src)
+		draw.Draw(img, r.Inset(1), image.NewUniform(fill), image.Point{}, draw.Src)
+
+		// 没有字体,用文字占位条表示主题内容
+		tw := int(math.Round(math.Min(n.text, n.w-24) * scale))
+		th := int(math.Max(1, math.Round(n.h*scale/5)))
+		cx, cy := (x0+x1)/2, (y0+y1)/2
+		draw.Draw(img, image.Rect(cx-tw/2, cy-th/2, cx-tw/2+tw, cy-th/2+th),
+			image.NewUniform(text), image.Point{}, draw.Src)
+	}
+	box(root)
+	root.walk(func(_, n *thumbNode) { box(n) })
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// 使用Bresenham算法画线
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := x1-x0, y1-y0
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+
+	for e := dx - dy; ; {
+		img.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		if e2 := 2 * e; e2 > -dy {
+			e -= dy
+			x0 += sx
+		} else {
+			e += dx
+			y0 += sy
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package xmind
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 )
 
@@ -11,6 +13,14 @@ const (
 	incrKey TopicID = "incr" // 自增主题key
 )
 
+// Try 开头的方法以及 Lookup 返回的错误,可以使用 errors.Is 判断
+var (
+	ErrNotFound        = errors.New("topic not found")
+	ErrCyclicMove      = errors.New("can not move topic into itself or its descendants")
+	ErrRootNotEditable = errors.New("root topic is not editable")
+	ErrSpecialID       = errors.New("special topic id is not supported")
+)
+
 // NewSheet 创建一个画布
 //
 //	param
@@ -104,6 +114,48 @@ func (st *Topic) OnTitle(title string) *Topic {
 	return st.On(st.CId(title)) // 两个操作合并为一个,方便使用
 }
 
+// Lookup 同 On 根据主题ID切换主题地址,找不到时不会切换到最后编辑的主题,而是返回错误
+//
+//	param
+//		componentId: 主题ID,为 CentKey 时切换到中心主题
+//	return
+//		*Topic: 匹配主题地址
+//		error: ErrSpecialID 内部使用的特殊ID,ErrNotFound 找不到主题
+func (st *Topic) Lookup(componentId TopicID) (*Topic, error) {
+	if st == nil || st.resources == nil {
+		return nil, ErrNotFound
+	}
+
+	if componentId.isSpecial() {
+		return nil, ErrSpecialID
+	}
+	tp, ok := st.find(componentId)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	st.resources[lastKey] = tp
+	return tp, nil
+}
+
+// 判断是内部使用的特殊ID,不包括 CentKey
+func (t TopicID) isSpecial() bool {
+	return t == rootKey || t == lastKey || t == incrKey
+}
+
+// 判断当前主题可以添加或移入主题,根节点不支持,已删除的主题视为找不到
+func (st *Topic) editable() error {
+	if st == nil {
+		return ErrNotFound
+	}
+	if st.parent == nil {
+		return ErrRootNotEditable
+	}
+	if tp, ok := st.find(st.ID); !ok || tp != st {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // Parent 返回父节点地址,如果传参则返回指定ID的父节点
 // 找不到父主题,或父主题为nil时需要外部自行判断
 func (st *Topic) Parent(componentId ...TopicID) *Topic {
@@ -128,8 +180,8 @@ type AddMode uint8
 
 const (
 	SubMode    AddMode = iota // 默认方式,当前主题添加子主题
-	BeforeMode                // 在当前主题之前插入
-	AfterMode                 // 在当前主题之后插入
+	BeforeMode                // 在当前主题之前插入,当前主题不是普通子主题时同 SubMode
+	AfterMode                 // 在当前主题之后插入,当前主题不是普通子主题时同 SubMode
 	ParentMode                // 为当前主题插入父主题
 )
 
@@ -156,12 +208,198 @@ func (st *Topic) Add(title string, modes ...AddMode) *Topic {
 		// 没有对外提供切换到根节点方法,除非外部直接使用 Topic 对象
 		return st
 	}
+	return st.add(title, modes...)
+}
+
+// TryAdd 同 Add 为当前主题添加主题,失败时返回错误
+//
+//	param
+//		title: 主题内容
+//		mode: 添加主题方式,不传则默认添加子主题
+//	return
+//		*Topic: 当前主题地址,同 Add
+//		error: ErrRootNotEditable 当前为根节点,ErrNotFound 当前主题已被删除
+//
+// 和 Add 不同,当前主题已被删除时返回错误,Add 仍然会在已删除的主题上添加
+func (st *Topic) TryAdd(title string, modes ...AddMode) (*Topic, error) {
+	if err := st.editable(); err != nil {
+		return st, err
+	}
+	return st.add(title, modes...), nil
+}
 
+func (st *Topic) add(title string, modes ...AddMode) *Topic {
 	mode := SubMode
 	if len(modes) > 0 {
 		mode = modes[0].In()
 	}
 
+	tp := st.newTopic(title)
+
+	// 当前节点插入父主题,当前节点为中心主题时不管啥选项都是添加子主题
+	if mode == ParentMode && st != st.resources[CentKey] {
+		st.Title, tp.Title = tp.Title, st.Title // 不用关心资源
+		tp.Children = st.Children
+		st.Children = &Children{Attached: []*Topic{tp}}
+		// 概要,外框和编号都作用于子主题,需要跟着子主题一起转移
+		tp.Summaries, st.Summaries = st.Summaries, nil
+		tp.Boundaries, st.Boundaries = st.Boundaries, nil
+		tp.Numbering, st.Numbering = st.Numbering, nil
+		for _, tc := range tp.Children.topics() {
+			tc.parent = tp // 所有该级子节点更新父节点指针
+		}
+		// 由于st,tp交换,所以这里返回tp,保证当前位置还是之前的定位
+		return st.On(tp.ID)
+	}
+
+	st.insert(tp, mode)
+	return st
+}
+
+// 将tp插入到当前主题对应位置,mode只支持 SubMode,BeforeMode,AfterMode
+// 当前节点为中心主题,或者不是普通子主题(自由主题,概要主题,标注)时,不管啥选项都是添加子主题
+func (st *Topic) insert(tp *Topic, mode AddMode) {
+	idx := -1 // 当前节点在父节点普通子主题中的下标
+	if mode != SubMode && st != st.resources[CentKey] && st.parent.Children != nil {
+		for i, tc := range st.parent.Children.Attached {
+			if tc == st {
+				idx = i
+				break
+			}
+		}
+	}
+
+	if idx < 0 {
+		tp.parent = st
+		if st.Children == nil {
+			st.Children = &Children{Attached: []*Topic{tp}}
+		} else {
+			st.Children.Attached = append(st.Children.Attached, tp)
+		}
+		return
+	}
+
+	// 下面只有2种同级插入方式,更新该节点父节点信息,插入兄弟节点后更新概要和外框范围
+	parent := st.parent
+	tp.parent = parent
+	defer parent.holdRanges()()
+
+	if mode == AfterMode {
+		idx++ // 当前节点后插入主题,否则在当前节点前插入主题
+	}
+	tps := append(parent.Children.Attached, nil)
+	copy(tps[idx+1:], tps[idx:])
+	tps[idx] = tp
+	parent.Children.Attached = tps
+}
+
+// AddDetached 为当前主题添加自由主题
+//
+//	param
+//		title: 主题内容
+//		x,y: 自由主题坐标
+//	return
+//		*Topic: 当前主题地址
+//
+// xmind中自由主题一般添加在中心主题上,使用 On 切换到中心主题后添加即可
+func (st *Topic) AddDetached(title string, x, y float64) *Topic {
+	if st == nil || st.parent == nil {
+		return st // 同 Add 根节点不支持添加主题
+	}
+
+	tp := st.newTopic(title)
+	tp.Position = &Position{X: x, Y: y}
+	if st.Children == nil {
+		st.Children = &Children{Detached: []*Topic{tp}}
+	} else {
+		st.Children.Detached = append(st.Children.Detached, tp)
+	}
+	return st
+}
+
+// Detached 返回当前主题的所有自由主题,删除自由主题使用 RemoveByID 即可
+func (st *Topic) Detached() []*Topic {
+	if st == nil || st.Children == nil {
+		return nil
+	}
+	return st.Children.Detached
+}
+
+// AddCallout 为当前主题添加标注,标注是附在主题上的小气泡
+//
+//	param
+//		title: 标注内容
+//	return
+//		*Topic: 当前主题地址
+//
+// 标注会在 Range 中被遍历到,通过 Type 返回 TopicCallout 区分
+func (st *Topic) AddCallout(title string) *Topic {
+	if st == nil || st.parent == nil {
+		return st // 同 Add 根节点不支持添加主题
+	}
+
+	tp := st.newTopic(title)
+	if st.Children == nil {
+		st.Children = &Children{Callout: []*Topic{tp}}
+	} else {
+		st.Children.Callout = append(st.Children.Callout, tp)
+	}
+	return st
+}
+
+// Callouts 返回当前主题的所有标注,删除标注使用 RemoveByID 即可
+func (st *Topic) Callouts() []*Topic {
+	if st == nil || st.Children == nil {
+		return nil
+	}
+	return st.Children.Callout
+}
+
+// SetPosition 设置当前主题坐标,一般只对自由主题有效
+//
+//	param
+//		x,y: 主题坐标
+//	return
+//		*Topic: 当前主题地址
+func (st *Topic) SetPosition(x, y float64) *Topic {
+	st.Position = &Position{X: x, Y: y}
+	return st
+}
+
+// Type 返回当前主题的类型
+//
+//	return
+//		TopicType: 中心主题返回 TopicRoot,画布或游离的主题返回空
+func (st *Topic) Type() TopicType {
+	if st == nil || st.parent == nil {
+		return ""
+	}
+	if st.parent.RootTopic == st {
+		return TopicRoot
+	}
+
+	if st.parent.Children != nil {
+		for _, tp := range st.parent.Children.Detached {
+			if tp == st {
+				return TopicDetached
+			}
+		}
+		for _, tp := range st.parent.Children.Summary {
+			if tp == st {
+				return TopicSummary
+			}
+		}
+		for _, tp := range st.parent.Children.Callout {
+			if tp == st {
+				return TopicCallout
+			}
+		}
+	}
+	return TopicAttached
+}
+
+// 创建一个新主题并添加到资源中,父节点为当前节点
+func (st *Topic) newTopic(title string) *Topic {
 	if title == "" {
 		id, ok := st.resources[incrKey]
 		if ok {
@@ -173,56 +411,67 @@ func (st *Topic) Add(title string, modes ...AddMode) *Topic {
 	id := GetId()
 	tp := &Topic{ID: id, Title: title, resources: st.resources, parent: st}
 	tp.resources[id] = tp
+	return tp
+}
 
-	// 添加子主题,当前节点为中心主题时不管啥选项都是添加子主题
-	if mode == SubMode || st == st.resources[CentKey] {
-		if st.Children == nil {
-			st.Children = &Children{Attached: []*Topic{tp}}
-		} else {
-			st.Children.Attached = append(st.Children.Attached, tp)
-		}
-		return st
+// 返回所有类型的子主题,依次为[普通主题,自由主题,概要主题,标注]
+func (c *Children) topics() []*Topic {
+	if c == nil {
+		return nil
+	}
+	if len(c.Detached) == 0 && len(c.Summary) == 0 && len(c.Callout) == 0 {
+		return c.Attached
 	}
+	res := make([]*Topic, 0, len(c.Attached)+len(c.Detached)+len(c.Summary)+len(c.Callout))
+	return append(append(append(append(res, c.Attached...), c.Detached...), c.Summary...), c.Callout...)
+}
 
-	// 当前节点插入父主题
-	if mode == ParentMode {
-		st.Title, tp.Title = tp.Title, st.Title // 不用关心资源
-		tp.Children = st.Children
-		st.Children = &Children{Attached: []*Topic{tp}}
-		if tp.Children != nil && len(tp.Children.Attached) > 0 {
-			for _, tc := range tp.Children.Attached {
-				tc.parent = tp // 所有该级子节点更新父节点指针
+// 在所有类型的子主题中删除指定ID的主题,返回被删除的主题,找不到时返回nil
+func (c *Children) remove(componentId TopicID) *Topic {
+	for _, tps := range []*[]*Topic{&c.Attached, &c.Detached, &c.Summary, &c.Callout} {
+		for i, tp := range *tps {
+			if tp.ID == componentId {
+				*tps = append((*tps)[:i], (*tps)[i+1:]...)
+				return tp
 			}
 		}
-		// 由于st,tp交换,所以这里返回tp,保证当前位置还是之前的定位
-		return st.On(tp.ID)
 	}
+	return nil
+}
+
+// 判断没有任何类型的子主题
+func (c *Children) isEmpty() bool {
+	return len(c.Attached) == 0 && len(c.Detached) == 0 && len(c.Summary) == 0 && len(c.Callout) == 0
+}
 
-	tp.parent = st.parent // 下面只有2种同级插入方式,更新该节点父节点信息
-	if st.parent.Children == nil {
-		st.parent.Children = &Children{Attached: []*Topic{tp}}
-		return st // 应该没有这种情况,保险而已
+// 在当前主题的子主题中移除指定ID的主题,同时移除引用该主题的概要
+// 只是从树上摘除,不会删除资源,返回被移除的主题,找不到时返回nil
+func (st *Topic) removeChild(componentId TopicID) *Topic {
+	if st.Children == nil {
+		return nil
 	}
-	tps := append(st.parent.Children.Attached, tp)
 
-	if mode == BeforeMode {
-		for i := len(tps) - 1; i > 0; i-- {
-			tps[i], tps[i-1] = tps[i-1], tps[i]
-			if tps[i].ID == st.ID {
-				break // 当前节点前插入主题
-			}
-		}
-	} else if mode == AfterMode {
-		for i := len(tps) - 1; i > 0; i-- {
-			if tps[i-1].ID == st.ID {
-				break // 当前节点后插入主题
-			}
-			tps[i], tps[i-1] = tps[i-1], tps[i]
-		}
+	tp := st.Children.remove(componentId)
+	if tp == nil {
+		return nil
+	}
+	if st.Children.isEmpty() {
+		st.Children = nil
 	}
 
-	st.parent.Children.Attached = tps
-	return st
+	cur := 0
+	for i, sm := range st.Summaries {
+		if sm.TopicID != componentId {
+			st.Summaries[cur] = st.Summaries[i]
+			cur++
+		}
+	}
+	if cur == 0 {
+		st.Summaries = nil
+	} else {
+		st.Summaries = st.Summaries[:cur]
+	}
+	return tp
 }
 
 // Move 将指定节点移动到当前节点对应位置
@@ -233,8 +482,40 @@ func (st *Topic) Add(title string, modes ...AddMode) *Topic {
 //	return
 //		*Topic: 当前主题地址
 func (st *Topic) Move(componentId TopicID, modes ...AddMode) *Topic {
-	if st == nil || st.parent == nil || !componentId.IsOrdinary() {
-		return st // 同 Add 根节点不支持操作,内部的特殊节点不支持移动操作
+	if st == nil || st.parent == nil {
+		return st // 同 Add 根节点不支持操作
+	}
+	tp, _ := st.move(componentId, modes...)
+	return tp
+}
+
+// TryMove 同 Move 将指定节点移动到当前节点对应位置,失败时返回错误
+//
+//	param
+//		componentId: 要移动过来的节点
+//		modes: 移动过来的添加方式,不传则默认移动为最后一个子主题
+//	return
+//		*Topic: 当前主题地址
+//		error: ErrSpecialID 内部使用的特殊ID,ErrNotFound 找不到节点,
+//			ErrRootNotEditable 移动根节点或中心主题,ErrCyclicMove 移动到自己或子孙节点
+//
+// 和 Move 不同,当前主题已被删除时返回错误,Move 仍然会移动到已删除的主题上
+func (st *Topic) TryMove(componentId TopicID, modes ...AddMode) (*Topic, error) {
+	if err := st.editable(); err != nil {
+		return st, err // 同 Add 根节点不支持操作
+	}
+	return st.move(componentId, modes...)
+}
+
+func (st *Topic) move(componentId TopicID, modes ...AddMode) (*Topic, error) {
+	if cent := st.resources[CentKey]; cent != nil && componentId == cent.ID {
+		return st, ErrRootNotEditable // 中心主题不支持移动
+	}
+	if componentId.isSpecial() {
+		return st, ErrSpecialID // 内部的特殊节点不支持移动操作
+	}
+	if !componentId.IsOrdinary() {
+		return st, ErrNotFound // 普通主题的ID都是标准格式
 	}
 
 	mode := SubMode
@@ -247,72 +528,28 @@ func (st *Topic) Move(componentId TopicID, modes ...AddMode) *Topic {
 
 	src, ok := st.resources[componentId]
 	if !ok {
-		return st // 找不到节点,无法移动
+		return st, ErrNotFound // 找不到节点,无法移动
 	}
 	parent := src.parent
-	if parent == nil || parent.Children == nil || len(parent.Children.Attached) == 0 {
-		return st // 被移动节点没有父节点,或者父节点没有子节点(貌似没这情况,以防万一)
-	}
-
-	for p := st; p.parent != nil; p = p.parent {
-		if p.parent == src {
-			return st // 被移动的节点是当前节点的祖辈节点,不支持被移动
-		}
-	}
-
-	cur := 0
-	for i, tp := range parent.Children.Attached {
-		if tp.ID != src.ID {
-			parent.Children.Attached[cur] = parent.Children.Attached[i]
-			cur++ // 注意不能直接用tp赋值,range的坑
-		}
-	}
-	if cur == len(parent.Children.Attached) {
-		return st // 没有找到要移动的节点
-	}
-	// 在父节点的子节点中移除需要移动的节点
-	if cur == 0 {
-		parent.Children = nil
-	} else {
-		parent.Children.Attached = parent.Children.Attached[:cur]
+	if parent == nil || parent.Children == nil {
+		return st, ErrNotFound // 被移动节点没有父节点,或者父节点没有子节点(貌似没这情况,以防万一)
 	}
 
-	// 添加子主题,当前节点为中心主题时不管啥选项都是移动到子主题
-	if mode == SubMode || st == st.resources[CentKey] {
-		src.parent = st // 更新被移动节点的父节点为当前节点
-		if st.Children == nil {
-			st.Children = &Children{Attached: []*Topic{src}}
-		} else {
-			st.Children.Attached = append(st.Children.Attached, src)
+	for p := st; p != nil; p = p.parent {
+		if p == src {
+			return st, ErrCyclicMove // 被移动的节点是当前节点或当前节点的祖辈节点,不支持被移动
 		}
-		return st
-	}
-
-	src.parent = st.parent // 下面只有2种同级插入方式,更新该节点父节点信息
-	if st.parent.Children == nil {
-		st.parent.Children = &Children{Attached: []*Topic{src}}
-		return st // 应该没有这种情况,保险而已
 	}
-	tps := append(st.parent.Children.Attached, src)
 
-	if mode == BeforeMode {
-		for i := len(tps) - 1; i > 0; i-- {
-			tps[i], tps[i-1] = tps[i-1], tps[i]
-			if tps[i].ID == st.ID {
-				break // 当前节点前插入主题
-			}
-		}
-	} else if mode == AfterMode {
-		for i := len(tps) - 1; i > 0; i-- {
-			if tps[i-1].ID == st.ID {
-				break // 当前节点后插入主题
-			}
-			tps[i], tps[i-1] = tps[i-1], tps[i]
-		}
+	// 在父节点的子节点中移除需要移动的节点,移除后更新概要和外框范围
+	defer parent.holdRanges(src)()
+	if parent.removeChild(src.ID) == nil {
+		return st, ErrNotFound // 没有找到要移动的节点
 	}
+	src.Position = nil // 移动后都作为普通子主题,不需要保留自由主题的坐标
 
-	st.parent.Children.Attached = tps
-	return st
+	st.insert(src, mode) // 更新被移动节点的父节点,并插入到对应位置
+	return st, nil
 }
 
 // Remove 删除指定主题内容节点
@@ -334,59 +571,128 @@ func (st *Topic) Remove(title string) *Topic {
 //
 // 特别注意,删除主题成功会自动定位到中心主题上,如果需要切换需要显示使用 On 操作
 func (st *Topic) RemoveByID(componentId TopicID) *Topic {
-	if st == nil || !componentId.IsOrdinary() {
-		return st // 特殊主题不允许删除
-	}
+	tp, _ := st.TryRemove(componentId)
+	return tp
+}
 
-	topic := st.Parent(componentId)
-	if topic == nil || topic.Children == nil || len(topic.Children.Attached) == 0 {
-		return st
+// TryRemove 同 RemoveByID 删除指定主题ID的节点,失败时返回错误
+//
+//	param
+//		componentId: 待删除主题ID
+//	return
+//		*Topic: 删除成功时返回中心主题地址,失败时返回当前主题地址
+//		error: ErrRootNotEditable 删除中心主题,ErrSpecialID 内部使用的特殊ID,ErrNotFound 找不到主题
+func (st *Topic) TryRemove(componentId TopicID) (*Topic, error) {
+	if st == nil {
+		return st, ErrNotFound
 	}
-
-	cur := 0 // 找到需要删除节点父节点地址,遍历所有子节点并删除匹配项
-	for i, tp := range topic.Children.Attached {
-		if tp.ID != componentId {
-			topic.Children.Attached[cur] = topic.Children.Attached[i]
-			cur++ // 注意不能直接用tp赋值,range的坑
-		} else {
-			delete(st.resources, tp.ID) // 删除当前节点
-			tp.RemoveChildren()         // 递归删除子节点
-		}
+	if cent := st.resources[CentKey]; componentId == CentKey || (cent != nil && componentId == cent.ID) {
+		return st, ErrRootNotEditable // 中心主题不允许删除
+	}
+	if componentId.isSpecial() {
+		return st, ErrSpecialID // 特殊主题不允许删除
 	}
-	if cur == len(topic.Children.Attached) {
-		return st // 没有匹配删除直接返回
+	if !componentId.IsOrdinary() {
+		return st, ErrNotFound // 普通主题的ID都是标准格式
 	}
 
-	if cur == 0 {
-		topic.Children = nil
-	} else {
-		topic.Children.Attached = topic.Children.Attached[:cur]
+	topic := st.Parent(componentId)
+	if topic == nil || topic.Children == nil {
+		return st, ErrNotFound
+	}
+
+	// 找到需要删除节点父节点地址,在所有类型子节点中删除匹配项,删除后更新概要和外框范围
+	fix := topic.holdRanges()
+	tp := topic.removeChild(componentId)
+	if tp == nil {
+		return st, ErrNotFound // 没有匹配删除直接返回
 	}
+	delete(st.resources, tp.ID) // 删除当前节点
+	tp.removeChildren()         // 递归删除子节点
+	fix()
+	st.cleanRelationships() // 删除指向已删除节点的联系
 	// 存在删除时,需要切换到中心主题上,避免在已删除节点执行后续逻辑
-	return st.On()
+	return st.On(), nil
 }
 
 // RemoveChildren 递归删除所有子节点
 func (st *Topic) RemoveChildren() {
-	if st != nil && st.Children != nil {
-		for _, tp := range st.Children.Attached {
+	st.removeChildren()
+	st.cleanRelationships() // 删除指向已删除节点的联系
+}
+
+func (st *Topic) removeChildren() {
+	if st == nil {
+		return
+	}
+	if st.Children != nil {
+		for _, tp := range st.Children.topics() {
 			delete(st.resources, tp.ID)
-			tp.RemoveChildren()
+			tp.removeChildren()
 		}
 		st.Children = nil
 	}
+	st.Summaries = nil // 概要主题和概要包含的子主题都已删除
+
+	cur := 0 // 只保留包含整个主题的外框
+	for i, b := range st.Boundaries {
+		if b.Range == BoundaryMaster {
+			st.Boundaries[cur] = st.Boundaries[i]
+			cur++
+		}
+	}
+	if cur == 0 {
+		st.Boundaries = nil
+	} else {
+		st.Boundaries = st.Boundaries[:cur]
+	}
+}
+
+// 初始化画布主题的资源信息,用于通过文件加载或拷贝的画布
+func (st *Topic) initSheet() {
+	incr := 0
+	st.RootTopic.parent = st
+	st.RootTopic.resources = map[TopicID]*Topic{
+		rootKey: st,
+		CentKey: st.RootTopic,
+		lastKey: st.RootTopic,
+		incrKey: {incr: &incr},
+	}
+	st.resources = st.RootTopic.resources
+
+	ids := make(map[TopicID]TopicID)
+	if !st.RootTopic.ID.IsOrdinary() {
+		id := GetId() // 中心主题也需要正常ID,否则联系无法指向中心主题
+		ids[st.RootTopic.ID], st.RootTopic.ID = id, id
+	}
+	// 准备初始化数据,从中心主题开始更新所有子节点数据
+	st.RootTopic.upChildren(ids)
+	if len(ids) > 0 { // 主题链接和任务依赖也引用了重新生成的ID
+		_ = st.Range(func(_ int, tp *Topic) error {
+			tp.remapIDs(tp, ids)
+			return nil
+		})
+	}
+	st.RootTopic.upRelationships(ids)
 }
 
 // 为节点所有子节点添加父节点地址指针,并且更新资源数据
-func (st *Topic) upChildren() {
+// 非普通ID会重新生成,ids记录[旧ID]新ID的对应关系,用于更新引用这些ID的数据
+func (st *Topic) upChildren(ids map[TopicID]TopicID) {
 	if st != nil && st.Children != nil {
-		for _, tp := range st.Children.Attached {
+		for _, tp := range st.Children.topics() {
 			if !tp.ID.IsOrdinary() {
-				tp.ID = GetId() // 生成正常ID
+				id := GetId() // 生成正常ID,同时更新引用该主题的概要
+				for _, sm := range st.Summaries {
+					if sm.TopicID == tp.ID {
+						sm.TopicID = id
+					}
+				}
+				ids[tp.ID], tp.ID = id, id
 			}
 			st.resources[tp.ID] = tp
 			tp.parent, tp.resources = st, st.resources
-			tp.upChildren() // 递归更新所有子节点资源
+			tp.upChildren(ids) // 递归更新所有子节点资源
 		}
 	}
 }
@@ -470,7 +776,7 @@ func (st *Topic) IsCent() bool {
 	return st != nil && st == st.resources[CentKey]
 }
 
-// Range 从当前节点递归遍历子节点
+// Range 从当前节点递归遍历子节点,包括自由主题,概要主题和标注
 //
 //	param
 //		f: 外部的回调
@@ -481,11 +787,9 @@ func (st *Topic) Range(f func(int, *Topic) error) error {
 			if tp != nil {
 				// 通过回调函数让调用者实现自己的逻辑
 				if err = f(deep, tp); err == nil {
-					if tp.Children != nil && len(tp.Children.Attached) > 0 {
-						for _, tc := range tp.Children.Attached {
-							if err = loop(deep+1, tc); err != nil {
-								return
-							}
+					for _, tc := range tp.Children.topics() {
+						if err = loop(deep+1, tc); err != nil {
+							return
 						}
 					}
 				}
@@ -511,20 +815,29 @@ func (st *Topic) Resources() (res map[TopicID]*Topic) {
 		// 返回副本,修改返回值不会影响当前对象
 		for id, topic := range st.resources {
 			res[id] = &Topic{
-				ID:     topic.ID,
-				Title:  topic.Title,
-				Branch: topic.Branch,
-				Href:   topic.Href,
-				Labels: append([]string(nil), topic.Labels...),
-				Style:  topic.Style,
+				ID:      topic.ID,
+				Title:   topic.Title,
+				Branch:  topic.Branch,
+				Href:    topic.Href,
+				Labels:  append([]string(nil), topic.Labels...),
+				Markers: append([]Marker(nil), topic.Markers...),
+				Style:   topic.Style,
 
 				StructureClass: topic.StructureClass,
 			}
 
+			if topic.Image != nil {
+				img := *topic.Image
+				res[id].Image = &img
+			}
+
 			if topic.Notes != nil {
 				res[id].Notes = &Notes{Plain: ContentStruct{
 					Content: topic.Notes.Plain.Content,
-				}}
+				}, HTML: append(json.RawMessage(nil), topic.Notes.HTML...)}
+				if topic.Notes.RealHTML != nil {
+					res[id].Notes.RealHTML = &ContentStruct{Content: topic.Notes.RealHTML.Content}
+				}
 			}
 		}
 	} else {
@@ -533,6 +846,108 @@ func (st *Topic) Resources() (res map[TopicID]*Topic) {
 	return
 }
 
+// Sheet 返回当前主题所在的画布主题,找不到时返回nil
+func (st *Topic) Sheet() *Topic {
+	if st == nil {
+		return nil
+	}
+	return st.resources[rootKey]
+}
+
+// Relate 在画布中为两个主题添加联系
+//
+//	param
+//		fromID: 起点主题ID
+//		toID: 终点主题ID
+//		title: 联系内容,可以为空
+//	return
+//		*Topic: 当前主题地址
+//
+// 任意一个主题不存在时不做任何操作,联系保存在画布主题中,删除主题时会自动删除相关联系
+func (st *Topic) Relate(fromID, toID TopicID, title string) *Topic {
+	root := st.Sheet()
+	if root == nil {
+		return st
+	}
+
+	from, ok := st.find(fromID)
+	if !ok {
+		return st
+	}
+	to, ok := st.find(toID)
+	if !ok || from == to {
+		return st
+	}
+
+	root.Relationships = append(root.Relationships, &Relationship{
+		ID:     GetId(),
+		End1ID: from.ID,
+		End2ID: to.ID,
+		Title:  title,
+	})
+	return st
+}
+
+// 根据ID查找主题,支持中心主题的ID,内部使用的特殊ID只支持 CentKey
+func (st *Topic) find(componentId TopicID) (*Topic, bool) {
+	if st == nil || st.resources == nil {
+		return nil, false
+	}
+
+	cent := st.resources[CentKey]
+	if componentId == CentKey || (cent != nil && componentId == cent.ID) {
+		return cent, cent != nil
+	}
+	if !componentId.IsOrdinary() {
+		return nil, false
+	}
+	tp, ok := st.resources[componentId]
+	return tp, ok
+}
+
+// 删除画布中指向不存在主题的联系
+func (st *Topic) cleanRelationships() {
+	root := st.Sheet()
+	if root == nil || len(root.Relationships) == 0 {
+		return
+	}
+
+	cur := 0
+	for i, r := range root.Relationships {
+		if _, ok := st.find(r.End1ID); !ok {
+			continue
+		}
+		if _, ok := st.find(r.End2ID); !ok {
+			continue
+		}
+		root.Relationships[cur] = root.Relationships[i]
+		cur++
+	}
+	if cur == 0 {
+		root.Relationships = nil
+	} else {
+		root.Relationships = root.Relationships[:cur]
+	}
+}
+
+// 根据ID对应关系更新画布中联系的主题ID,并删除指向不存在主题的联系
+func (st *Topic) upRelationships(ids map[TopicID]TopicID) {
+	root := st.Sheet()
+	if root == nil {
+		return
+	}
+
+	for _, r := range root.Relationships {
+		if id, ok := ids[r.End1ID]; ok {
+			r.End1ID = id
+		}
+		if id, ok := ids[r.End2ID]; ok {
+			r.End2ID = id
+		}
+	}
+	st.cleanRelationships()
+}
+
 // AddLabel 在当前主题上加label标签
 //
 //	param
@@ -546,6 +961,87 @@ func (st *Topic) AddLabel(label ...string) *Topic {
 	return st
 }
 
+// AddMarker 在当前主题上加图标
+//
+//	param
+//		markers: 图标ID,同一组的图标会替换已存在的图标,例如优先级只能有一个
+//	return
+//		*Topic: 当前主题地址
+func (st *Topic) AddMarker(markers ...MarkerID) *Topic {
+	for _, id := range markers {
+		if id == "" {
+			continue
+		}
+
+		replaced := false
+		for i, m := range st.Markers {
+			if m.MarkerId.Group() == id.Group() {
+				st.Markers[i].MarkerId, replaced = id, true
+				break
+			}
+		}
+		if !replaced {
+			st.Markers = append(st.Markers, Marker{MarkerId: id})
+		}
+	}
+	return st
+}
+
+// RemoveMarker 删除当前主题上的图标
+//
+//	param
+//		markers: 图标ID,不传则删除所有图标
+//	return
+//		*Topic: 当前主题地址
+func (st *Topic) RemoveMarker(markers ...MarkerID) *Topic {
+	if len(markers) == 0 {
+		st.Markers = nil
+		return st
+	}
+
+	cur := 0
+	for i, m := range st.Markers {
+		remove := false
+		for _, id := range markers {
+			if m.MarkerId == id {
+				remove = true
+				break
+			}
+		}
+		if !remove {
+			st.Markers[cur] = st.Markers[i]
+			cur++
+		}
+	}
+	if cur == 0 {
+		st.Markers = nil
+	} else {
+		st.Markers = st.Markers[:cur]
+	}
+	return st
+}
+
+// HasMarker 判断当前主题上存在指定图标
+func (st *Topic) HasMarker(marker MarkerID) bool {
+	if st != nil {
+		for _, m := range st.Markers {
+			if m.MarkerId == marker {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 返回所有图标ID字符串,方便模板和自定义json使用
+func (st *Topic) markerIds() []string {
+	ids := make([]string, len(st.Markers))
+	for i, m := range st.Markers {
+		ids[i] = string(m.MarkerId)
+	}
+	return ids
+}
+
 // AddNotes 在当前主题上加notes备注
 //
 //	param
@@ -571,6 +1067,7 @@ func (st *Topic) AddNotes(notes string) *Topic {
 //		AddHref("file:content.json"), 相对路径,会打开当前xmind目录的content.json文件
 //		AddHref("file://D:/content.json"), 绝对路径,会打开D:/content.json文件,路径分隔符为'/'
 //	主题超链接: AddHref("xmind:#" + string(st2.CId("title"))), 链接到其他主题
+//	附件: 使用 AddAttachment 添加,超链接为 "xap:resources/文件名"
 func (st *Topic) AddHref(href string) *Topic {
 	if href != "" {
 		st.Href = href
@@ -13,6 +13,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // LoadFile 从文件加载xmind数据
@@ -33,8 +34,20 @@ func LoadFile(path string) (*WorkBook, error) {
 	return wk, nil
 }
 
-// LoadFrom 从文件或io.Reader对象中加载xmind
+// LoadFrom 从文件或io.Reader对象中加载xmind,加密的文件使用 LoadFromWithPassword 加载
 func LoadFrom(input any) (*WorkBook, error) {
+	return loadFrom(input, "")
+}
+
+// LoadFromWithPassword 使用密码加载加密的xmind文件,没有加密的文件会忽略密码
+//
+//	return
+//		error: 密码错误时返回 *PasswordError
+func LoadFromWithPassword(input any, password string) (*WorkBook, error) {
+	return loadFrom(input, password)
+}
+
+func loadFrom(input any, password string) (*WorkBook, error) {
 	var (
 		read interface {
 			io.ReaderAt
@@ -74,18 +87,7 @@ func LoadFrom(input any) (*WorkBook, error) {
 			if topic == nil || topic.RootTopic == nil {
 				continue // 剔除不合法的数据
 			}
-
-			incr := 0
-			topic.RootTopic.parent = topic
-			topic.RootTopic.resources = map[TopicID]*Topic{
-				rootKey: topic,
-				CentKey: topic.RootTopic,
-				lastKey: topic.RootTopic,
-				incrKey: {incr: &incr},
-			}
-			topic.resources = topic.RootTopic.resources
-			// 准备初始化数据,从中心主题开始更新所有子节点数据
-			topic.RootTopic.upChildren()
+			topic.initSheet()
 			sheets = append(sheets, topic)
 		}
 		wb.Topics = sheets
@@ -93,24 +95,29 @@ func LoadFrom(input any) (*WorkBook, error) {
 
 	zr, err := zip.NewReader(read, size)
 	if err == nil {
-		rz, err := zr.Open(ContentJson)
-		if err == nil {
-			//goland:noinspection GoUnhandledErrorResult
-			defer rz.Close()
+		ar, err := newArchiveReader(zr, password)
+		if err != nil {
+			return nil, err // 加密文件没有传密码
+		}
 
-			err = json.NewDecoder(rz).Decode(&wb.Topics)
+		data, err := ar.read(ContentJson)
+		if err == nil {
+			err = json.Unmarshal(data, &wb.Topics)
 			if err == nil {
+				wb.loadArchive(ar)
 				return &wb, nil // 尝试读取zip中的content.json文件成功
 			}
+		} else if ar.encrypted(ContentJson) {
+			return nil, err // 密码错误,或者不支持的加密算法
 		}
 
-		rz, err = zr.Open(ContentXml)
+		data, err = ar.read(ContentXml)
 		if err == nil {
-			//goland:noinspection GoUnhandledErrorResult
-			defer rz.Close()
-
-			err = xml.NewDecoder(rz).Decode(&wb)
+			err = xml.Unmarshal(data, &wb)
 			if err == nil {
+				// xmind8的描述文件已经转换,不需要保留
+				wb.loadArchive(ar, ContentXml, StylesXml, MetaXml, ManifestXml)
+				wb.loadLegacy(ar)
 				return &wb, nil // 尝试读取zip中的content.xml文件成功
 			}
 		}
@@ -133,7 +140,8 @@ func LoadFrom(input any) (*WorkBook, error) {
 	if err = seekFile(func() error {
 		return xml.NewDecoder(read).Decode(&wb)
 	}); err == nil {
-		return &wb, nil // 尝试直接用xml方式读取成功
+		wb.resolveStyles(nil) // 没有 styles.xml,删除所有 style-id
+		return &wb, nil       // 尝试直接用xml方式读取成功
 	}
 	return nil, errors.New("can not read xmind")
 }
@@ -147,6 +155,10 @@ const (
 	CustomKeyNotes    = "Notes"
 	CustomKeyBranch   = "Branch"
 	CustomKeyHref     = "Href"
+	CustomKeyMarkers  = "Markers"
+	CustomKeyProgress = "Progress" // 任务进度,取值范围 0~1
+	CustomKeyDue      = "Due"      // 任务截止时间,格式为 RFC3339,保存时使用UTC时区
+	CustomKeyNumber   = "Number"   // 主题编号,只在 SaveCustom 中生成
 )
 
 func fillCustom(custom map[string]string) map[string]string {
@@ -155,7 +167,8 @@ func fillCustom(custom map[string]string) map[string]string {
 	}
 
 	for _, v := range []string{CustomKeyId, CustomKeyTitle, CustomKeyParentId,
-		CustomKeyLabels, CustomKeyNotes, CustomKeyBranch, CustomKeyHref} {
+		CustomKeyLabels, CustomKeyNotes, CustomKeyBranch, CustomKeyHref, CustomKeyMarkers,
+		CustomKeyProgress, CustomKeyDue, CustomKeyNumber} {
 		if _, ok := custom[v]; !ok {
 			// 没有传的参数填充默认值,tag标签用小写
 			custom[v] = strings.ToLower(v)
@@ -202,6 +215,9 @@ func fillCustom(custom map[string]string) map[string]string {
 //	        CustomKeyNotes:    "notes",    // 以该json tag字段作为主题备注
 //	        CustomKeyBranch:   "branch",   // 以该json tag字段作为主题折叠状态
 //	        CustomKeyHref:     "href",     // 以该json tag字段作为主题超链接
+//	        CustomKeyMarkers:  "markers",  // 以该json tag字段作为主题图标,例如: ["priority-1"]
+//	        CustomKeyProgress: "progress", // 以该json tag字段作为任务进度,例如: 0.5
+//	        CustomKeyDue:      "due",      // 以该json tag字段作为任务截止时间,例如: "2024-01-02T15:04:05Z"
 //	      })
 //	return
 //	  *Topic: 生成的主题地址
@@ -253,6 +269,21 @@ func LoadCustom(data any, custom map[string]string) (sheet *Topic, err error) {
 			Name: CustomKeyHref, Type: strType,
 			Tag: reflect.StructTag(`json:"` + custom[CustomKeyHref] + `"`),
 		},
+		{
+			Name: CustomKeyMarkers,
+			Type: reflect.TypeOf([]MarkerID{}),
+			Tag:  reflect.StructTag(`json:"` + custom[CustomKeyMarkers] + `"`),
+		},
+		{
+			Name: CustomKeyProgress,
+			Type: reflect.TypeOf((*float64)(nil)), // 指针类型用于区分没有该字段
+			Tag:  reflect.StructTag(`json:"` + custom[CustomKeyProgress] + `"`),
+		},
+		{
+			Name: CustomKeyDue,
+			Type: reflect.TypeOf((*time.Time)(nil)),
+			Tag:  reflect.StructTag(`json:"` + custom[CustomKeyDue] + `"`),
+		},
 	}
 
 	isRootKey, hasRoot := custom[CustomKeyIsRoot]
@@ -291,25 +322,51 @@ func LoadCustom(data any, custom map[string]string) (sheet *Topic, err error) {
 		notes := stu.Field(4).String()
 		branch := stu.Field(5).String()
 		href := stu.Field(6).String()
+		markers := stu.Field(7).Interface().([]MarkerID)
+		progress := stu.Field(8).Interface().(*float64)
+		due := stu.Field(9).Interface().(*time.Time)
 
 		// 优先根据IsRoot字段判断当前节点是根节点
-		if (hasRoot && stu.Field(7).Bool()) || parentId == "" {
+		if (hasRoot && stu.FieldByName(CustomKeyIsRoot).Bool()) || parentId == "" {
 			sheet = NewSheet("sheet", title)
+			sheet.AddMarker(markers...).setCustomTask(progress, due)
 			idMap[id] = CentKey // 建立中心主题ID映射关系
 		} else {
-			find := sheet.On(idMap[parentId]).Add(title).AddLabel(labels...).
-				AddHref(href).AddNotes(notes)
-			if branch == folded {
-				find.Folded() // 收缩主题
-			}
+			find := sheet.On(idMap[parentId]).Add(title)
 			last := find.Children.Attached
 			// 将刚才添加的子主题ID建立映射关系,刚添加的子主题一定是最后一个
-			idMap[id] = last[len(last)-1].ID
+			added := last[len(last)-1]
+			idMap[id] = added.ID
+
+			added.AddLabel(labels...).AddHref(href).AddNotes(notes).AddMarker(markers...).
+				setCustomTask(progress, due)
+			if branch == folded {
+				added.Folded() // 收缩主题
+			}
 		}
 	}
 	return
 }
 
+// 根据自定义数据设置任务进度和截止时间,都为nil时不做任何操作
+func (st *Topic) setCustomTask(progress *float64, due *time.Time) {
+	if progress == nil && due == nil {
+		return
+	}
+
+	task := st.Task()
+	if task == nil {
+		task = &TaskInfo{}
+	}
+	if progress != nil {
+		task.Progress = *progress
+	}
+	if due != nil {
+		task.Due = *due
+	}
+	st.SetTask(task)
+}
+
 // LoadCustomWorkbook 加载自定义workbook的json
 func LoadCustomWorkbook(input io.Reader, custom map[string]string) (*WorkBook, error) {
 	var data []json.RawMessage
@@ -335,32 +392,71 @@ func (wk *WorkBook) check() error {
 	return nil
 }
 
+// SaveOption 保存xmind文件的选项
+type SaveOption func(*saveOption)
+
+type saveOption struct {
+	thumbnail bool   // 生成缩略图
+	password  string // 加密密码,参考 WithPassword
+}
+
+// WithThumbnail 保存时根据第一个画布生成缩略图 ThumbnailPath,替换加载文件时保留的缩略图
+func WithThumbnail() SaveOption {
+	return func(o *saveOption) { o.thumbnail = true }
+}
+
 // SaveTo 将xmind保存到io.Writer对象,使用更灵活
-func (wk *WorkBook) SaveTo(w io.Writer) error {
+//
+// 默认不生成缩略图,加载文件时保留的缩略图会原样写回,需要生成时使用 WithThumbnail
+// 使用 WithPassword 时加密保存,不会生成缩略图
+func (wk *WorkBook) SaveTo(w io.Writer, opts ...SaveOption) error {
 	err := wk.check()
 	if err != nil {
 		return err
 	}
 
+	var opt saveOption
+	for _, o := range opts {
+		o(&opt)
+	}
+
 	cp := make([]*Topic, 0, len(wk.Topics))
 	for _, topic := range wk.Topics {
 		// 所有sheet全部切换到根节点,最终使用存入的cp生成xmind文件
 		cp = append(cp, topic.On(rootKey))
 	}
 
-	zw := zip.NewWriter(w)
-	//goland:noinspection GoUnhandledErrorResult
-	defer zw.Close()
-
-	wz, err := zw.Create(ContentJson)
+	aw := newArchiveWriter(w)
+	aw.password = opt.password
+	err = aw.writeJSON(ContentJson, cp)
+	if err != nil {
+		return err
+	}
+	if opt.thumbnail && opt.password == "" {
+		thumb, err := renderThumbnail(cp)
+		if err != nil {
+			return err
+		}
+		if thumb != nil { // 生成的缩略图替换加载文件时保留的缩略图
+			err = aw.write(ThumbnailPath, thumb)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	err = wk.saveEntries(aw) // 写入加载文件时保留的其他文件
 	if err != nil {
 		return err
 	}
-	return json.NewEncoder(wz).Encode(cp)
+	err = wk.saveResources(aw) // 写入图片等资源文件
+	if err != nil {
+		return err
+	}
+	return aw.close(wk.Metadata) // 最后写入 metadata.json 和 manifest.json
 }
 
-// Save 保存对象为 *.xmind 文件
-func (wk *WorkBook) Save(path string) error {
+// Save 保存对象为 *.xmind 文件,opts 参考 SaveTo
+func (wk *WorkBook) Save(path string, opts ...SaveOption) error {
 	if filepath.Ext(path) != ".xmind" {
 		return fmt.Errorf("%s: suffix must be .xmind", path)
 	}
@@ -372,7 +468,7 @@ func (wk *WorkBook) Save(path string) error {
 	//goland:noinspection GoUnhandledErrorResult
 	defer fw.Close()
 
-	return wk.SaveTo(fw)
+	return wk.SaveTo(fw, opts...)
 }
 
 // SaveSheets 保存多个sheet画布到一个xmind文件
@@ -397,11 +493,17 @@ var RootIsNull = errors.New("RootTopic is null")
 //	        // "isRoot,xx",表示只添加根节点
 //	    CustomKeyLabels: "labels", // 以该json tag字段作为标签
 //	    CustomKeyNotes:  "notes",  // 以该json tag字段作为备注
+//	    CustomKeyMarkers: "markers", // 以该json tag字段作为图标
+//	    CustomKeyProgress: "progress", // 以该json tag字段作为任务进度,没有任务信息时不添加
+//	    CustomKeyDue: "due",           // 以该json tag字段作为任务截止时间,没有截止时间时不添加
+//	    CustomKeyNumber: "number",     // 以该json tag字段作为主题编号,没有编号时不添加
 //	  }
 //	  v: 可以为 *string,*[]byte,*[]Nodes{} 这几种类型
 //	  genId: 外部自定义生成id方案,自动生成的id是参照xmind,可能有点长
 //	return
 //	  error: 返回错误
+//
+// 自定义结构只能通过父节点id表示普通子主题,自由主题,概要主题,标注以及它们的子主题不会保存
 func SaveCustom(sheet *Topic, custom map[string]string, v any,
 	genId func(id TopicID) string) error {
 	cent := sheet.On()
@@ -427,18 +529,27 @@ func SaveCustom(sheet *Topic, custom map[string]string, v any,
 	}
 
 	var (
-		idKey     = custom[CustomKeyId]
-		titleKey  = custom[CustomKeyTitle]
-		parentKey = custom[CustomKeyParentId]
-		labelsKey = custom[CustomKeyLabels]
-		notesKey  = custom[CustomKeyNotes]
-		branchKey = custom[CustomKeyBranch]
-		hrefKey   = custom[CustomKeyHref]
+		idKey       = custom[CustomKeyId]
+		titleKey    = custom[CustomKeyTitle]
+		parentKey   = custom[CustomKeyParentId]
+		labelsKey   = custom[CustomKeyLabels]
+		notesKey    = custom[CustomKeyNotes]
+		branchKey   = custom[CustomKeyBranch]
+		hrefKey     = custom[CustomKeyHref]
+		markersKey  = custom[CustomKeyMarkers]
+		progressKey = custom[CustomKeyProgress]
+		dueKey      = custom[CustomKeyDue]
+		numberKey   = custom[CustomKeyNumber]
 	)
 	parentKey, _, ok = strings.Cut(parentKey, ",")
 
+	skip := make(map[*Topic]bool) // 不是普通子主题的主题,以及它们的子孙主题
 	_ = cent.Range(func(_ int, tp *Topic) error {
 		isCent := tp.IsCent()
+		if !isCent && (skip[tp.parent] || tp.Type() != TopicAttached) {
+			skip[tp] = true
+			return nil
+		}
 		if isCent {
 			buf.WriteString(`[{"`) // 中心主题为数组第一个元素
 		} else {
@@ -507,6 +618,40 @@ func SaveCustom(sheet *Topic, custom map[string]string, v any,
 		buf.WriteString(`":`) // 添加超链接
 		buf.Write(strconv.AppendQuote(quote[:0], tp.Href))
 
+		if len(tp.Markers) > 0 {
+			buf.WriteString(`,"`)
+			buf.WriteString(markersKey)
+			buf.WriteString(`":[`) // 添加图标,没有图标时不输出该字段
+			for i, m := range tp.Markers {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				buf.Write(strconv.AppendQuote(quote[:0], string(m.MarkerId)))
+			}
+			buf.WriteByte(']')
+		}
+
+		if num := tp.Number(); num != "" {
+			buf.WriteString(`,"`)
+			buf.WriteString(numberKey)
+			buf.WriteString(`":`) // 添加主题编号
+			buf.Write(strconv.AppendQuote(quote[:0], num))
+		}
+
+		if task := tp.Task(); task != nil {
+			buf.WriteString(`,"`)
+			buf.WriteString(progressKey)
+			buf.WriteString(`":`) // 添加任务进度
+			buf.Write(strconv.AppendFloat(quote[:0], task.Progress, 'f', -1, 64))
+
+			if !task.Due.IsZero() {
+				buf.WriteString(`,"`)
+				buf.WriteString(dueKey)
+				buf.WriteString(`":`) // 添加任务截止时间
+				buf.Write(strconv.AppendQuote(quote[:0], task.Due.UTC().Format(time.RFC3339)))
+			}
+		}
+
 		buf.WriteString(`,"`)
 		buf.WriteString(labelsKey)
 		buf.WriteString(`":[`) // 添加标签